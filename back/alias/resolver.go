@@ -0,0 +1,74 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+)
+
+// Index holds one pointer document per alias, written by
+// `load-data index switch`
+const Index = "enron_aliases"
+
+// DefaultTTL is how long a resolved alias is cached. A switch made by the
+// loader is picked up by the backend within this delay.
+const DefaultTTL = 10 * time.Second
+
+// Alias is the pointer document of an alias
+type Alias struct {
+	Alias     string    `json:"alias"`
+	Index     string    `json:"index"`
+	Previous  string    `json:"previous,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Resolver maps the index names used by the handlers to the versioned
+// indexes they point to. ZincSearch has no index aliases, so aliases are
+// stored as pointer documents. A name without a pointer document resolves
+// to itself, so a plain enron_emails index keeps working.
+type Resolver struct {
+	Client *db.ZincClient
+	// Index holds the pointer documents, Index by default
+	Index string
+	cache *cache.Cache[string, string]
+}
+
+// NewResolver creates a new instance of Resolver
+func NewResolver(client *db.ZincClient, ttl time.Duration) *Resolver {
+	return &Resolver{
+		Client: client,
+		Index:  Index,
+		cache:  cache.New[string, string](ttl, 0),
+	}
+}
+
+// CacheStats returns the hits and misses of the resolved aliases cache
+func (r *Resolver) CacheStats() cache.Stats {
+	return r.cache.Stats()
+}
+
+// Resolve returns the index name points to
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if index, ok := r.cache.Get(name); ok {
+		return index, nil
+	}
+
+	index := name
+	var a Alias
+	err := r.Client.GetDocument(ctx, r.Index, name, &a)
+	switch {
+	case err == nil && a.Index != "":
+		index = a.Index
+	case err != nil && !db.IsNotFound(err):
+		return "", fmt.Errorf("failed to resolve alias %s: %w", name, err)
+	}
+
+	r.cache.Set(name, index)
+	return index, nil
+}
@@ -3,42 +3,176 @@ package app
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/back/autocomplete"
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
 	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/localindex"
+	"github.com/DanielOsorio01/enron-email-search/back/metrics"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/spelling"
+	"github.com/DanielOsorio01/enron-email-search/back/terms"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
 )
 
 type App struct {
 	router   http.Handler
 	dbClient *db.ZincClient
-	config   Config
+	aliases  *alias.Resolver
+	datasets *dataset.Registry
+	// searcher serves the email searches, emails is the same repository
+	// when the search backend is ZincSearch and nil otherwise
+	searcher  email.EmailSearcher
+	emails    *email.ZincsearchRepo
+	termStats *terms.Stats
+	spelling  *spelling.Dictionary
+	people    *autocomplete.People
+	metrics   *metrics.Metrics
+	// profiles caches the person profiles of every dataset by index and
+	// address
+	profiles *cache.Cache[string, *models.PersonProfile]
+	// defaultDataset is searched by the routes without a dataset
+	defaultDataset models.Dataset
+	config         Config
 }
 
-func New(config Config) *App {
+// New creates the app serving config. It fails when a configured file, such
+// as the emails of the memory or local backend, cannot be loaded.
+func New(config Config) (*App, error) {
 	app := &App{
-		dbClient: db.NewZincClient(
-			config.dbAddr,
-			config.dbUser,
-			config.dbPassword),
-		config: config,
+		metrics:        metrics.New(),
+		defaultDataset: dataset.Default,
+		config:         config,
 	}
+	app.defaultDataset.Index = config.Indexes.Emails
+	app.defaultDataset.PeopleMetricsIndex = config.Indexes.PeopleMetrics
+	config.zincOptions.Observe = app.metrics.ObserveZinc
+	app.dbClient = db.NewZincClientWithOptions(
+		config.Zinc.URL,
+		config.Zinc.User,
+		config.Zinc.Password,
+		config.zincOptions)
+	app.aliases = alias.NewResolver(app.dbClient, alias.DefaultTTL)
+	app.aliases.Index = config.Indexes.Aliases
+	app.datasets = dataset.NewRegistry(app.dbClient, dataset.DefaultTTL)
+	app.datasets.Index = config.Indexes.Datasets
+	app.datasets.Default = app.defaultDataset
+	app.metrics.RegisterCache("aliases", app.aliases.CacheStats)
+	app.metrics.RegisterCache("datasets", app.datasets.CacheStats)
+
+	switch config.Search.Backend {
+	case BackendMemory:
+		memory := email.NewMemoryRepo()
+		if config.Search.MemoryEmailsPath != "" {
+			n, err := memory.LoadFile(app.defaultDataset.Index, config.Search.MemoryEmailsPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load emails: %w", err)
+			}
+			slog.Info("emails loaded in memory", "emails", n)
+		}
+		app.searcher = memory
+		app.datasets.Client = nil
+	case BackendLocal:
+		memory := email.NewMemoryRepo()
+		names, err := loadLocalIndexes(memory, config.Search.LocalIndexPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load local indexes: %w", err)
+		}
+		app.searcher = memory
+		// Without a registry every dataset index of the directory is listed
+		app.datasets.Client = nil
+		for _, name := range names {
+			if d, ok := dataset.FromEmailsIndex(name); ok && name != app.defaultDataset.Index {
+				app.datasets.Fixed = append(app.datasets.Fixed, d)
+			}
+		}
+	default:
+		app.emails = &email.ZincsearchRepo{Client: app.dbClient, Aliases: app.aliases}
+		app.searcher = app.emails
+	}
+
+	// Term statistics are optional, without them related terms are unavailable
+	if config.Files.TermStats != "" {
+		stats, err := terms.Load(config.Files.TermStats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load term statistics: %w", err)
+		}
+		app.termStats = stats
+	}
+
+	// Without a dictionary searches simply come back without suggestions
+	if config.Files.Dictionary != "" {
+		dictionary, err := spelling.Load(config.Files.Dictionary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load spelling dictionary: %w", err)
+		}
+		app.spelling = dictionary
+	}
+
+	if config.Files.People != "" {
+		people, err := autocomplete.NewPeople(config.Files.People)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load people index: %w", err)
+		}
+		app.people = people
+	}
+
 	app.loadRoutes()
-	return app
+	return app, nil
+}
+
+// Handler returns the router serving the API
+func (a *App) Handler() http.Handler {
+	return a.router
 }
 
 func (a *App) Start(ctx context.Context) error {
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", a.config.serverPort),
-		Handler: a.router,
+		Addr:              fmt.Sprintf(":%d", a.config.Server.Port),
+		Handler:           a.router,
+		ReadHeaderTimeout: a.config.Server.ReadHeaderTimeout,
+		ReadTimeout:       a.config.Server.ReadTimeout,
+		WriteTimeout:      a.config.Server.WriteTimeout,
+		IdleTimeout:       a.config.Server.IdleTimeout,
 	}
-	// Ping the database to check if it's up
-	err := a.dbClient.Ping()
+	shutdownTracing, err := tracing.Setup(ctx, "enron-backend", a.config.Tracing.Exporter)
 	if err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctxTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctxTimeout); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	if a.emails != nil {
+		// Ping the database to check if it's up
+		err = a.dbClient.Ping(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
+
+		if err := a.checkEmailsIndex(ctx); err != nil {
+			return err
+		}
+
+		slog.Info("database is up, starting server", "port", a.config.Server.Port)
+	} else {
+		slog.Info("starting server", "backend", a.config.Search.Backend, "port", a.config.Server.Port)
 	}
 
-	fmt.Println("Database is up. Starting server...")
+	go a.reloadOnHangup(ctx)
 
 	ch := make(chan error, 1)
 
@@ -54,9 +188,74 @@ func (a *App) Start(ctx context.Context) error {
 	case err = <-ch:
 		return err
 	case <-ctx.Done():
-		ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctxTimeout, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
 		defer cancel()
 		return server.Shutdown(ctxTimeout)
 	}
 
 }
+
+// checkEmailsIndex refuses to serve an index whose mapping the queries do
+// not support. A fresh deployment starts before load-data created the index,
+// so a missing index only keeps /readyz failing until it is loaded.
+func (a *App) checkEmailsIndex(ctx context.Context) error {
+	err := a.emails.CheckMapping(ctx, a.defaultDataset.Index)
+	switch {
+	case db.IsNotFound(err):
+		slog.Warn("emails index does not exist yet, not ready until it is loaded", "index", a.defaultDataset.Index, "error", err)
+	case err != nil:
+		return fmt.Errorf("failed to check index mapping: %w", err)
+	}
+	return nil
+}
+
+// loadLocalIndexes serves every index of the local store in dir
+func loadLocalIndexes(memory *email.MemoryRepo, dir string) ([]string, error) {
+	store, err := localindex.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := store.Names()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		ix, err := store.Index(name)
+		if err != nil {
+			return nil, err
+		}
+		memory.SetIndex(name, ix)
+		slog.Info("local index loaded", "index", name, "emails", ix.Len())
+	}
+	return names, nil
+}
+
+// reloadOnHangup reloads the files that can change while the server runs,
+// the synonyms and the people index, every time a SIGHUP is received
+func (a *App) reloadOnHangup(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if a.config.synonyms != nil {
+				if err := a.config.synonyms.Reload(); err != nil {
+					slog.Error("failed to reload synonyms", "error", err)
+				} else {
+					slog.Info("synonyms reloaded")
+				}
+			}
+			if a.people != nil {
+				if err := a.people.Reload(); err != nil {
+					slog.Error("failed to reload people index", "error", err)
+				} else {
+					slog.Info("people index reloaded")
+				}
+			}
+		}
+	}
+}
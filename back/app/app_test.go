@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestStartsBeforeTheIndexIsLoaded(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+	t.Setenv("DB_HOST", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", server.Password)
+	t.Setenv("SEARCH_BACKEND", BackendZinc)
+
+	config, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A missing index does not stop the backend, it only is not ready
+	if err := a.checkEmailsIndex(ctx); err != nil {
+		t.Errorf("expected a missing index to be accepted, got %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	a.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the backend not to be ready without its index, got status %d", recorder.Code)
+	}
+
+	// An index whose mapping the queries do not support does
+	err = server.Client().CreateIndex(ctx, db.IndexDefinition{
+		Name:     config.Indexes.Emails,
+		Mappings: db.Mappings{Properties: map[string]db.Property{"date": {Type: "text"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.checkEmailsIndex(ctx); err == nil {
+		t.Error("expected an incompatible mapping to stop the backend")
+	}
+}
+
+func TestRefusesMissingLocalIndexes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("SEARCH_BACKEND", BackendLocal)
+	t.Setenv("LOCAL_INDEX_PATH", dir)
+
+	config, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(*config); err == nil {
+		t.Error("expected the backend to refuse a missing local index directory")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the backend not to create %s, got %v", dir, err)
+	}
+}
+
+func TestRefusesInvalidFiles(t *testing.T) {
+	// The configuration checks that the files exist, not that they parse
+	invalid := writeFile(t, "invalid.json", "{not json")
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"memory emails", map[string]string{"SEARCH_BACKEND": BackendMemory, "MEMORY_EMAILS_PATH": invalid}},
+		{"term statistics", map[string]string{"TERM_STATS_PATH": invalid}},
+		{"dictionary", map[string]string{"DICTIONARY_PATH": invalid}},
+		{"people index", map[string]string{"PEOPLE_INDEX_PATH": invalid}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("DB_PASSWORD", "secret")
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			config, err := LoadConfig(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := New(*config); err == nil {
+				t.Errorf("expected the backend to refuse an invalid %s file", test.name)
+			}
+		})
+	}
+}
+
+func TestListDatasetsConcurrently(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+	t.Setenv("DB_HOST", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", server.Password)
+	t.Setenv("SEARCH_BACKEND", BackendZinc)
+
+	config, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Add(config.Indexes.Emails, models.Email{MessageID: "<1>"}, models.Email{MessageID: "<2>"}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run with -race: the counts must not be written to the cached registry
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			a.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/datasets/", nil))
+			var response struct {
+				Data []models.Dataset `json:"data"`
+			}
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Error(err)
+				return
+			}
+			if len(response.Data) != 1 || response.Data[0].Documents != 2 {
+				t.Errorf("expected the default dataset with 2 emails, got %+v", response.Data)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cached, err := a.datasets.Get(context.Background(), a.defaultDataset.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Documents != 0 {
+		t.Errorf("expected no count in the registry, got %d", cached.Documents)
+	}
+}
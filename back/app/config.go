@@ -1,34 +1,410 @@
 package app
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/network"
+	"github.com/DanielOsorio01/enron-email-search/back/synonyms"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
 )
 
+// Search backends
+const (
+	BackendZinc   = "zinc"
+	BackendMemory = "memory"
+	BackendLocal  = "local"
+)
+
+// redacted replaces the secrets printed by -print-config
+const redacted = "[redacted]"
+
+// Settings is the configuration of the backend. It is read from a YAML file,
+// then overridden by environment variables and then by flags.
+type Settings struct {
+	Server  ServerSettings  `yaml:"server"`
+	Zinc    ZincSettings    `yaml:"zinc"`
+	CORS    CORSSettings    `yaml:"cors"`
+	Search  SearchSettings  `yaml:"search"`
+	Indexes IndexSettings   `yaml:"indexes"`
+	Limits  LimitSettings   `yaml:"limits"`
+	Files   FileSettings    `yaml:"files"`
+	Log     LogSettings     `yaml:"log"`
+	Tracing TracingSettings `yaml:"tracing"`
+}
+
+// ServerSettings configures the HTTP server, a zero timeout disables it
+type ServerSettings struct {
+	Port              int           `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long the requests in flight are given to
+	// complete once the server is asked to stop
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ReadinessTimeout bounds the checks of /readyz
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout"`
+}
+
+// ZincSettings configures the connection to ZincSearch. The password is
+// given either as is or as the path of a file holding it.
+type ZincSettings struct {
+	URL              string        `yaml:"url"`
+	User             string        `yaml:"user"`
+	Password         string        `yaml:"password"`
+	PasswordFile     string        `yaml:"password_file"`
+	Timeout          time.Duration `yaml:"timeout"`
+	MaxRetries       int           `yaml:"max_retries"`
+	BackoffBase      time.Duration `yaml:"backoff_base"`
+	BackoffMax       time.Duration `yaml:"backoff_max"`
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`
+}
+
+// CORSSettings lists the origins of the frontends allowed to call the API
+type CORSSettings struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// SearchSettings selects where emails are searched
+type SearchSettings struct {
+	// Backend is zinc, memory or local
+	Backend string `yaml:"backend"`
+	// MemoryEmailsPath points to a JSON lines file of emails loaded by the
+	// memory backend
+	MemoryEmailsPath string `yaml:"memory_emails_path"`
+	// LocalIndexPath is the directory of the local indexes written by
+	// `load-data --sink=local:/path`, served by the local backend
+	LocalIndexPath string `yaml:"local_index_path"`
+}
+
+// IndexSettings names the indexes or aliases the backend reads from
+type IndexSettings struct {
+	// Emails is searched by the routes without a dataset
+	Emails        string `yaml:"emails"`
+	PeopleMetrics string `yaml:"people_metrics"`
+	Datasets      string `yaml:"datasets"`
+	Aliases       string `yaml:"aliases"`
+}
+
+// LimitSettings bounds the work a single request can ask for
+type LimitSettings struct {
+	// MaxResults caps the emails returned by a search page
+	MaxResults int `yaml:"max_results"`
+	// MaxScanResults caps the emails read to build a graph or a concordance
+	MaxScanResults int `yaml:"max_scan_results"`
+}
+
+// FileSettings points to the files written by load-data, all optional
+type FileSettings struct {
+	TermStats  string `yaml:"term_stats"`
+	Dictionary string `yaml:"dictionary"`
+	People     string `yaml:"people"`
+	Synonyms   string `yaml:"synonyms"`
+}
+
+// LogSettings configures the JSON logs
+type LogSettings struct {
+	// Level is the lowest level logged: debug, info, warn or error
+	Level string `yaml:"level"`
+}
+
+// TracingSettings configures OpenTelemetry tracing
+type TracingSettings struct {
+	// Exporter is where spans are sent: none, otlp, stdout or file:/path
+	Exporter string `yaml:"exporter"`
+}
+
+// DefaultSettings returns the settings used when nothing overrides them
+func DefaultSettings() Settings {
+	zinc := db.DefaultOptions()
+	return Settings{
+		Server: ServerSettings{
+			Port:              3000,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   10 * time.Second,
+			ReadinessTimeout:  5 * time.Second,
+		},
+		Zinc: ZincSettings{
+			URL:              "http://localhost:4080",
+			User:             "admin",
+			Timeout:          zinc.Timeout,
+			MaxRetries:       zinc.MaxRetries,
+			BackoffBase:      zinc.BackoffBase,
+			BackoffMax:       zinc.BackoffMax,
+			BreakerThreshold: zinc.BreakerThreshold,
+			BreakerCooldown:  zinc.BreakerCooldown,
+		},
+		CORS: CORSSettings{
+			AllowedOrigins: []string{"http://localhost:8080", "http://127.0.0.1:8080", "http://localhost"},
+		},
+		Search: SearchSettings{
+			Backend:        BackendZinc,
+			LocalIndexPath: "./index",
+		},
+		Indexes: IndexSettings{
+			Emails:        dataset.Default.Index,
+			PeopleMetrics: network.Index,
+			Datasets:      dataset.Index,
+			Aliases:       alias.Index,
+		},
+		Limits: LimitSettings{
+			MaxResults:     1000,
+			MaxScanResults: email.MaxScanResults,
+		},
+		Log: LogSettings{
+			Level: "info",
+		},
+		Tracing: TracingSettings{
+			Exporter: tracing.DefaultExporter(),
+		},
+	}
+}
+
 type Config struct {
-	dbAddr     string
-	dbUser     string
-	dbPassword string
-	serverPort uint16
+	Settings
+	// PrintConfig asks to print the configuration instead of serving it
+	PrintConfig bool
+	// zincOptions tunes the timeouts, retries and circuit breaker of the
+	// Zinc client
+	zincOptions db.Options
+	// logLevel is the parsed Log.Level
+	logLevel slog.Level
+	// synonyms expands search terms, it is nil when no synonym file is configured
+	synonyms *synonyms.Store
+}
+
+// LoadConfig reads the configuration file named by the -config flag or the
+// CONFIG_FILE variable, applies the environment variables and then the
+// flags in args over it, and validates the result
+func LoadConfig(args []string) (*Config, error) {
+	settings := DefaultSettings()
+	overrides := settings.overrides()
+
+	flags := flag.NewFlagSet("back", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	printConfig := flags.Bool("print-config", false, "print the configuration with its secrets redacted and exit")
+	// Flags are applied last, once the file and the environment are read
+	var flagValues []func() error
+	for _, o := range overrides {
+		flags.Func(o.key, o.usage, func(value string) error {
+			flagValues = append(flagValues, func() error { return o.set(value) })
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := settings.readFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	for _, o := range overrides {
+		if value := os.Getenv(o.env); value != "" {
+			if err := o.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", o.env, err)
+			}
+		}
+	}
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			return nil, err
+		}
+	}
+
+	config := &Config{Settings: settings, PrintConfig: *printConfig}
+	if err := config.resolve(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LogLevel returns the lowest level logged
+func (c *Config) LogLevel() slog.Level {
+	return c.logLevel
+}
+
+// Print writes the configuration as YAML with its secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	settings := c.Settings
+	switch {
+	case settings.Zinc.PasswordFile != "":
+		// The password was read from the file, the path is enough
+		settings.Zinc.Password = ""
+	case settings.Zinc.Password != "":
+		settings.Zinc.Password = redacted
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(settings); err != nil {
+		return err
+	}
+	return encoder.Close()
 }
 
-func LoadConfig() *Config {
-	port, err := strconv.ParseUint(os.Getenv("SERVER_PORT"), 10, 16)
+// readFile reads a YAML configuration file over the settings, refusing
+// unknown keys so that a typo does not silently keep a default
+func (s *Settings) readFile(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		port = 3000
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if s.Zinc.Password != "" && s.Zinc.PasswordFile != "" {
+		return fmt.Errorf("config file %s sets both zinc.password and zinc.password_file", path)
+	}
+	return nil
+}
+
+// resolve reads the password file, validates the settings and derives the
+// rest of the configuration from them
+func (c *Config) resolve() error {
+	if c.Zinc.PasswordFile != "" {
+		data, err := os.ReadFile(c.Zinc.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read zinc.password_file: %w", err)
+		}
+		c.Zinc.Password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if err := c.Settings.validate(); err != nil {
+		return err
+	}
+
+	c.logLevel, _ = logging.ParseLevel(c.Log.Level)
+	c.zincOptions = db.Options{
+		Timeout:          c.Zinc.Timeout,
+		MaxRetries:       c.Zinc.MaxRetries,
+		BackoffBase:      c.Zinc.BackoffBase,
+		BackoffMax:       c.Zinc.BackoffMax,
+		BreakerThreshold: c.Zinc.BreakerThreshold,
+		BreakerCooldown:  c.Zinc.BreakerCooldown,
 	}
 
-	return &Config{
-		dbAddr:     getEnv("DB_HOST", "http://localhost:4080"),
-		dbUser:     getEnv("DB_USER", "admin"),
-		dbPassword: getEnv("DB_PASSWORD", "Complexpass#123"),
-		serverPort: uint16(port),
+	if c.Files.Synonyms != "" {
+		store, err := synonyms.Load(c.Files.Synonyms)
+		if err != nil {
+			return fmt.Errorf("failed to load synonyms: %w", err)
+		}
+		c.synonyms = store
 	}
+	return nil
+}
+
+// validate reports every invalid setting at once
+func (s *Settings) validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(s.Server.Port > 0 && s.Server.Port <= 65535, "server.port", "must be between 1 and 65535, got %d", s.Server.Port)
+	for key, d := range map[string]time.Duration{
+		"server.read_header_timeout": s.Server.ReadHeaderTimeout,
+		"server.read_timeout":        s.Server.ReadTimeout,
+		"server.write_timeout":       s.Server.WriteTimeout,
+		"server.idle_timeout":        s.Server.IdleTimeout,
+	} {
+		check(d >= 0, key, "must not be negative, got %s", d)
+	}
+	check(s.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive, got %s", s.Server.ShutdownTimeout)
+	check(s.Server.ReadinessTimeout > 0, "server.readiness_timeout", "must be positive, got %s", s.Server.ReadinessTimeout)
+
+	zincURL, err := url.Parse(s.Zinc.URL)
+	check(err == nil && (zincURL.Scheme == "http" || zincURL.Scheme == "https") && zincURL.Host != "",
+		"zinc.url", "must be an http or https URL, got %q", s.Zinc.URL)
+	if s.Search.Backend == BackendZinc {
+		check(s.Zinc.User != "", "zinc.user", "is required by the zinc backend")
+		check(s.Zinc.Password != "", "zinc.password", "is required by the zinc backend, set it or zinc.password_file")
+	}
+	check(s.Zinc.Timeout > 0, "zinc.timeout", "must be positive, got %s", s.Zinc.Timeout)
+	check(s.Zinc.MaxRetries >= 0, "zinc.max_retries", "must not be negative, got %d", s.Zinc.MaxRetries)
+	check(s.Zinc.BackoffBase > 0, "zinc.backoff_base", "must be positive, got %s", s.Zinc.BackoffBase)
+	check(s.Zinc.BackoffMax >= s.Zinc.BackoffBase, "zinc.backoff_max", "must be at least zinc.backoff_base, got %s", s.Zinc.BackoffMax)
+	check(s.Zinc.BreakerThreshold >= 0, "zinc.breaker_threshold", "must not be negative, 0 disables the breaker, got %d", s.Zinc.BreakerThreshold)
+	check(s.Zinc.BreakerCooldown > 0, "zinc.breaker_cooldown", "must be positive, got %s", s.Zinc.BreakerCooldown)
+
+	check(len(s.CORS.AllowedOrigins) > 0, "cors.allowed_origins", "must list at least one origin")
+	for _, origin := range s.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins", "%q is not an origin such as https://example.com or *", origin)
+	}
+
+	switch s.Search.Backend {
+	case BackendZinc, BackendMemory:
+	case BackendLocal:
+		check(s.Search.LocalIndexPath != "", "search.local_index_path", "is required by the local backend")
+	default:
+		check(false, "search.backend", "must be zinc, memory or local, got %q", s.Search.Backend)
+	}
+
+	for key, name := range map[string]string{
+		"indexes.emails":         s.Indexes.Emails,
+		"indexes.people_metrics": s.Indexes.PeopleMetrics,
+		"indexes.datasets":       s.Indexes.Datasets,
+		"indexes.aliases":        s.Indexes.Aliases,
+	} {
+		check(db.IsValidIndexName(name), key, "must hold lowercase letters, digits, dashes and underscores, got %q", name)
+	}
+
+	check(s.Limits.MaxResults > 0, "limits.max_results", "must be positive, got %d", s.Limits.MaxResults)
+	check(s.Limits.MaxScanResults > 0, "limits.max_scan_results", "must be positive, got %d", s.Limits.MaxScanResults)
+
+	// The files are optional, but one that is configured must exist
+	for key, path := range map[string]string{
+		"search.memory_emails_path": s.Search.MemoryEmailsPath,
+		"files.term_stats":          s.Files.TermStats,
+		"files.dictionary":          s.Files.Dictionary,
+		"files.people":              s.Files.People,
+		"files.synonyms":            s.Files.Synonyms,
+	} {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, key, "%v", err)
+		}
+	}
+
+	_, err = logging.ParseLevel(s.Log.Level)
+	check(err == nil, "log.level", "must be debug, info, warn or error, got %q", s.Log.Level)
+	check(tracing.ValidExporter(s.Tracing.Exporter), "tracing.exporter", "must be none, otlp, stdout or file:/path, got %q", s.Tracing.Exporter)
+
+	// Map iteration is random, keep the report stable
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// validOrigin reports whether origin is * or a scheme and host without a path
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
 	}
-	return defaultValue
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == ""
 }
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to a file of a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigOverrides(t *testing.T) {
+	passwordFile := writeFile(t, "password", "from-file\n")
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 8000
+  shutdown_timeout: 20s
+zinc:
+  url: http://zinc:4080
+  password_file: `+passwordFile+`
+cors:
+  allowed_origins: [https://search.example.com]
+indexes:
+  emails: enron_emails_v7
+`)
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("SERVER_PORT", "9000")
+	t.Setenv("ZINC_TIMEOUT", "3s")
+
+	config, err := LoadConfig([]string{"-config", path, "-server.port", "9100", "-limits.max_results", "50"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags win over the environment, which wins over the file
+	if config.Server.Port != 9100 {
+		t.Errorf("expected the port of the flag, got %d", config.Server.Port)
+	}
+	if config.Zinc.Timeout != 3*time.Second || config.zincOptions.Timeout != 3*time.Second {
+		t.Errorf("expected the zinc timeout of the environment, got %s", config.Zinc.Timeout)
+	}
+	if config.Server.ShutdownTimeout != 20*time.Second || config.Zinc.URL != "http://zinc:4080" || config.Indexes.Emails != "enron_emails_v7" {
+		t.Errorf("expected the settings of the file, got %+v", config.Settings)
+	}
+	if config.Limits.MaxResults != 50 {
+		t.Errorf("expected the max results of the flag, got %d", config.Limits.MaxResults)
+	}
+	// Settings missing from the file keep their default
+	if config.Zinc.User != "admin" || config.Indexes.Aliases != "enron_aliases" {
+		t.Errorf("expected the default user and aliases index, got %q and %q", config.Zinc.User, config.Indexes.Aliases)
+	}
+	if config.Zinc.Password != "from-file" {
+		t.Errorf("expected the password of the password file, got %q", config.Zinc.Password)
+	}
+
+	var printed bytes.Buffer
+	if err := config.Print(&printed); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(printed.String(), "from-file") || !strings.Contains(printed.String(), "port: 9100") {
+		t.Errorf("expected the printed configuration without the password, got:\n%s", printed.String())
+	}
+
+	// A password given in the environment replaces the password file
+	t.Setenv("DB_PASSWORD", "from-env")
+	config, err = LoadConfig([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed.Reset()
+	config.Print(&printed)
+	if config.Zinc.Password != "from-env" || config.Zinc.PasswordFile != "" || strings.Contains(printed.String(), "from-env") {
+		t.Errorf("expected the redacted password of the environment, got %q and:\n%s", config.Zinc.Password, printed.String())
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "invalid port",
+			env:  map[string]string{"SERVER_PORT": "80a", "DB_PASSWORD": "secret"},
+			want: []string{"server.port: invalid number"},
+		},
+		{
+			name: "no password",
+			env:  map[string]string{"DB_PASSWORD": ""},
+			want: []string{"zinc.password: is required"},
+		},
+		{
+			name: "unknown key",
+			file: "zinc:\n  pasword: secret\n",
+			want: []string{"field pasword not found"},
+		},
+		{
+			name: "password twice",
+			file: "zinc:\n  password: secret\n  password_file: /run/secrets/zinc\n",
+			want: []string{"both zinc.password and zinc.password_file"},
+		},
+		{
+			name: "every invalid setting",
+			file: `
+server:
+  port: 70000
+cors:
+  allowed_origins: [localhost:8080]
+search:
+  backend: elastic
+indexes:
+  emails: Enron Emails
+limits:
+  max_results: 0
+log:
+  level: loud
+`,
+			env: map[string]string{"DB_PASSWORD": "secret"},
+			want: []string{
+				"cors.allowed_origins:",
+				"indexes.emails:",
+				"limits.max_results:",
+				"log.level:",
+				"search.backend:",
+				"server.port:",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			var args []string
+			if test.file != "" {
+				args = []string{"-config", writeFile(t, "config.yaml", test.file)}
+			}
+
+			_, err := LoadConfig(args)
+			if err == nil {
+				t.Fatal("expected the configuration to be rejected")
+			}
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in the error, got:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// override binds a setting to the environment variable and the flag that
+// override it. The flag is named after the key of the setting in the
+// configuration file, such as -zinc.url.
+type override struct {
+	key   string
+	env   string
+	usage string
+	// target points to the setting, a string, an int, a duration or a list
+	// of strings given comma separated
+	target any
+	// clears is emptied when the setting is overridden, for the settings
+	// that are mutually exclusive such as a password and a password file
+	clears *string
+}
+
+// overrides lists the settings that can be overridden
+func (s *Settings) overrides() []override {
+	return []override{
+		{key: "server.port", env: "SERVER_PORT", usage: "port the server listens on", target: &s.Server.Port},
+		{key: "server.read_header_timeout", env: "SERVER_READ_HEADER_TIMEOUT", usage: "time allowed to read the request headers", target: &s.Server.ReadHeaderTimeout},
+		{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", usage: "time allowed to read a request", target: &s.Server.ReadTimeout},
+		{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", usage: "time allowed to write a response, 0 for no limit", target: &s.Server.WriteTimeout},
+		{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", usage: "time an idle keep-alive connection is kept", target: &s.Server.IdleTimeout},
+		{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT", usage: "time the requests in flight are given on shutdown", target: &s.Server.ShutdownTimeout},
+		{key: "server.readiness_timeout", env: "SERVER_READINESS_TIMEOUT", usage: "time allowed to the /readyz checks", target: &s.Server.ReadinessTimeout},
+
+		{key: "zinc.url", env: "DB_HOST", usage: "ZincSearch URL", target: &s.Zinc.URL},
+		{key: "zinc.user", env: "DB_USER", usage: "ZincSearch user", target: &s.Zinc.User},
+		{key: "zinc.password", env: "DB_PASSWORD", usage: "ZincSearch password, prefer zinc.password_file", target: &s.Zinc.Password, clears: &s.Zinc.PasswordFile},
+		{key: "zinc.password_file", env: "DB_PASSWORD_FILE", usage: "file holding the ZincSearch password", target: &s.Zinc.PasswordFile, clears: &s.Zinc.Password},
+		{key: "zinc.timeout", env: "ZINC_TIMEOUT", usage: "timeout of every attempt of a Zinc request", target: &s.Zinc.Timeout},
+		{key: "zinc.max_retries", env: "ZINC_MAX_RETRIES", usage: "retries of an idempotent Zinc request", target: &s.Zinc.MaxRetries},
+		{key: "zinc.backoff_base", env: "ZINC_BACKOFF_BASE", usage: "shortest delay between two attempts", target: &s.Zinc.BackoffBase},
+		{key: "zinc.backoff_max", env: "ZINC_BACKOFF_MAX", usage: "longest delay between two attempts", target: &s.Zinc.BackoffMax},
+		{key: "zinc.breaker_threshold", env: "ZINC_BREAKER_THRESHOLD", usage: "consecutive failures opening the circuit breaker, 0 disables it", target: &s.Zinc.BreakerThreshold},
+		{key: "zinc.breaker_cooldown", env: "ZINC_BREAKER_COOLDOWN", usage: "time the circuit breaker stays open", target: &s.Zinc.BreakerCooldown},
+
+		{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", usage: "comma separated origins allowed to call the API", target: &s.CORS.AllowedOrigins},
+
+		{key: "search.backend", env: "SEARCH_BACKEND", usage: "where emails are searched: zinc, memory or local", target: &s.Search.Backend},
+		{key: "search.memory_emails_path", env: "MEMORY_EMAILS_PATH", usage: "JSON lines file of emails loaded by the memory backend", target: &s.Search.MemoryEmailsPath},
+		{key: "search.local_index_path", env: "LOCAL_INDEX_PATH", usage: "directory of the local indexes served by the local backend", target: &s.Search.LocalIndexPath},
+
+		{key: "indexes.emails", env: "EMAILS_INDEX", usage: "index or alias searched by the routes without a dataset", target: &s.Indexes.Emails},
+		{key: "indexes.people_metrics", env: "PEOPLE_METRICS_INDEX", usage: "index or alias of the people metrics", target: &s.Indexes.PeopleMetrics},
+		{key: "indexes.datasets", env: "DATASETS_INDEX", usage: "index of the dataset registry", target: &s.Indexes.Datasets},
+		{key: "indexes.aliases", env: "ALIASES_INDEX", usage: "index of the alias pointers", target: &s.Indexes.Aliases},
+
+		{key: "limits.max_results", env: "MAX_RESULTS", usage: "most emails returned by a search page", target: &s.Limits.MaxResults},
+		{key: "limits.max_scan_results", env: "MAX_SCAN_RESULTS", usage: "most emails read to build a graph or a concordance", target: &s.Limits.MaxScanResults},
+
+		{key: "files.term_stats", env: "TERM_STATS_PATH", usage: "corpus term statistics written by load-data", target: &s.Files.TermStats},
+		{key: "files.dictionary", env: "DICTIONARY_PATH", usage: "spelling dictionary written by load-data", target: &s.Files.Dictionary},
+		{key: "files.people", env: "PEOPLE_INDEX_PATH", usage: "people autocomplete index written by load-data", target: &s.Files.People},
+		{key: "files.synonyms", env: "SYNONYMS_PATH", usage: "synonyms file", target: &s.Files.Synonyms},
+
+		{key: "log.level", env: "LOG_LEVEL", usage: "lowest level logged: debug, info, warn or error", target: &s.Log.Level},
+		{key: "tracing.exporter", env: "TRACES_EXPORTER", usage: "where spans are sent: none, otlp, stdout or file:/path", target: &s.Tracing.Exporter},
+	}
+}
+
+// set parses value into the setting
+func (o override) set(value string) error {
+	switch target := o.target.(type) {
+	case *string:
+		*target = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", o.key, value)
+		}
+		*target = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q, expected a value such as 500ms or 10s", o.key, value)
+		}
+		*target = d
+	case *[]string:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		*target = values
+	default:
+		return fmt.Errorf("%s: unsupported setting type %T", o.key, o.target)
+	}
+	if o.clears != nil {
+		*o.clears = ""
+	}
+	return nil
+}
@@ -2,45 +2,140 @@ package app
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
 	"github.com/DanielOsorio01/enron-email-search/back/handlers"
-	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/network"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
 )
 
 func (a *App) loadRoutes() {
 	router := chi.NewRouter()
 	router.Use(cors.Handler(cors.Options{
-		// Allow only your frontend's origin
-		AllowedOrigins: []string{"http://localhost:8080", "http://127.0.0.1:8080", "http://localhost"},
+		// Allow only the configured frontend origins
+		AllowedOrigins: a.config.CORS.AllowedOrigins,
 		// Restrict allowed HTTP methods
 		AllowedMethods: []string{"GET"},
 		// Allow specific headers (Content-Type for JSON requests, Authorization for tokens, etc.)
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", logging.RequestIDHeader},
+		// Let the frontend read the request ID to report errors with it
+		ExposedHeaders: []string{logging.RequestIDHeader},
 		// Allow credentials (cookies, authorization headers, etc.) only if necessary
 		AllowCredentials: false,
 		// Cache preflight responses for better performance
 		MaxAge: 300,
 	}))
-	router.Use(middleware.Logger)
+	router.Use(logging.RequestIDMiddleware)
+	router.Use(tracing.Middleware)
+	router.Use(logging.Middleware)
+	router.Use(a.metrics.Middleware)
+	router.Use(handlers.UseDataset(a.defaultDataset))
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	health := &handlers.Health{
+		Backend:  a.config.Search.Backend,
+		Index:    a.defaultDataset.Index,
+		Timeout:  a.config.Server.ReadinessTimeout,
+		Searcher: a.searcher,
+		Repo:     a.emails,
+	}
+	router.Get("/healthz", health.Live)
+	router.Get("/readyz", health.Ready)
+	router.Handle("/metrics", a.metrics.Handler())
+
 	router.Route("/emails", a.loadEmailRoutes)
+	router.Route("/autocomplete", a.loadAutocompleteRoutes)
+
+	// People and graphs are built from ZincSearch aggregations
+	if a.emails != nil {
+		a.profiles = cache.New[string, *models.PersonProfile](10*time.Minute, 1000)
+		a.metrics.RegisterCache("profiles", a.profiles.Stats)
+		router.Route("/people", a.loadPeopleRoutes)
+		router.Route("/graph", a.loadGraphRoutes)
+	}
+	router.Route("/datasets", a.loadDatasetRoutes)
 
 	a.router = router
 }
 
 func (a *App) loadEmailRoutes(router chi.Router) {
 	email := &handlers.Email{
-		Repo: &email.ZincsearchRepo{
-			Client: a.dbClient,
-		},
+		Searcher: a.searcher,
+		Repo:     a.emails,
+		Terms:    a.termStats,
+		Spelling: a.spelling,
+		Synonyms: a.config.synonyms,
+
+		MaxResults:     a.config.Limits.MaxResults,
+		MaxScanResults: a.config.Limits.MaxScanResults,
 	}
 
 	router.Get("/", email.List)
+	router.Get("/timeline", email.Timeline)
+	router.Get("/trends", email.Trends)
+	router.Get("/concordance", email.Concordance)
+	router.Get("/related", email.Related)
+	router.Get("/facets", email.Facets)
+	router.Get("/{id}", email.Get)
+}
+
+// loadDatasetRoutes serves the email routes of every registered dataset,
+// and its graph and people routes on ZincSearch. The routes mounted at the
+// root search the default dataset
+func (a *App) loadDatasetRoutes(router chi.Router) {
+	dataset := &handlers.Dataset{
+		Registry: a.datasets,
+		Searcher: a.searcher,
+	}
+
+	router.Get("/", dataset.List)
+	router.Route("/{dataset}", func(router chi.Router) {
+		router.Use(dataset.Select)
+		router.Route("/emails", a.loadEmailRoutes)
+		if a.emails != nil {
+			router.Route("/graph", a.loadGraphRoutes)
+			router.Route("/people", a.loadPeopleRoutes)
+		}
+	})
+}
+
+func (a *App) loadPeopleRoutes(router chi.Router) {
+	person := &handlers.Person{
+		Repo: a.emails,
+		Network: &network.ZincsearchRepo{
+			Client:  a.dbClient,
+			Aliases: a.aliases,
+		},
+		Profiles: a.profiles,
+	}
+
+	router.Get("/central", person.Central)
+	router.Get("/{id}/profile", person.Profile)
+	router.Get("/{id}/community", person.Community)
+}
+
+func (a *App) loadGraphRoutes(router chi.Router) {
+	graph := &handlers.Graph{
+		Repo:           a.emails,
+		MaxScanResults: a.config.Limits.MaxScanResults,
+	}
+
+	router.Get("/", graph.Get)
+}
+
+func (a *App) loadAutocompleteRoutes(router chi.Router) {
+	autocomplete := &handlers.Autocomplete{
+		PeopleIndex: a.people,
+	}
+
+	router.Get("/people", autocomplete.People)
+	router.Post("/people/refresh", autocomplete.Refresh)
 }
@@ -0,0 +1,49 @@
+package autocomplete
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// People serves the people index loaded from the file written by load-data
+// with the -people flag. The index can be reloaded while it is in use.
+type People struct {
+	path  string
+	index atomic.Pointer[Index]
+}
+
+// NewPeople loads the people index from path
+func NewPeople(path string) (*People, error) {
+	p := &People{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload rebuilds the index from the file. The previous index keeps being
+// served until the new one is ready, and is kept if loading fails.
+func (p *People) Reload() error {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to open people index: %w", err)
+	}
+	defer f.Close()
+
+	var file struct {
+		People []Person `json:"people"`
+	}
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
+		return fmt.Errorf("failed to parse people index: %w", err)
+	}
+
+	p.index.Store(NewIndex(file.People))
+	return nil
+}
+
+// Index returns the current index
+func (p *People) Index() *Index {
+	return p.index.Load()
+}
@@ -0,0 +1,153 @@
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+)
+
+// Person is an entry of the people index
+type Person struct {
+	Address   string `json:"address"`
+	Name      string `json:"name,omitempty"`
+	Canonical string `json:"canonical,omitempty"`
+	Messages  int    `json:"messages"`
+}
+
+// MaxCompletions is the number of completions kept for every prefix, the
+// most Complete returns
+const MaxCompletions = 50
+
+type node struct {
+	children map[rune]*node
+	// people holds the people with a key ending at the node, top the best
+	// ranked people with a key starting with the prefix of the node
+	people []int32
+	top    []int32
+}
+
+// Index is a prefix trie over the addresses, display names and canonical
+// names of people. Every word of a name is indexed as well, so "skil"
+// finds Jeff Skilling. The completions of every prefix are ranked when the
+// index is built.
+type Index struct {
+	root   *node
+	people []Person
+}
+
+// NewIndex builds the prefix trie of people
+func NewIndex(people []Person) *Index {
+	idx := &Index{root: &node{}, people: people}
+	for i, person := range people {
+		keys := map[string]bool{strings.ToLower(person.Address): true}
+		for _, name := range []string{person.Name, person.Canonical} {
+			if name == "" {
+				continue
+			}
+			keys[strings.ToLower(name)] = true
+			for _, word := range concordance.Tokenize(name) {
+				keys[word] = true
+			}
+		}
+		for key := range keys {
+			idx.insert(key, int32(i))
+		}
+	}
+	idx.rank(idx.root, "")
+	return idx
+}
+
+// Len returns the number of people in the index
+func (idx *Index) Len() int {
+	return len(idx.people)
+}
+
+func (idx *Index) insert(key string, person int32) {
+	n := idx.root
+	for _, r := range key {
+		child, ok := n.children[r]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[rune]*node)
+			}
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	n.people = append(n.people, person)
+}
+
+// rank computes the completions of n and of every node below it. The best
+// ranked people of a prefix are among the people of its node and the best
+// ranked people of its children, as a person never ranks higher on a
+// longer prefix.
+func (idx *Index) rank(n *node, prefix string) {
+	seen := make(map[int32]bool)
+	var candidates []int32
+	add := func(people []int32) {
+		for _, person := range people {
+			if !seen[person] {
+				seen[person] = true
+				candidates = append(candidates, person)
+			}
+		}
+	}
+	add(n.people)
+	for r, child := range n.children {
+		idx.rank(child, prefix+string(r))
+		add(child.top)
+	}
+
+	score := func(i int32) int {
+		person := idx.people[i]
+		if strings.HasPrefix(person.Address, prefix) ||
+			strings.HasPrefix(strings.ToLower(person.Name), prefix) ||
+			strings.HasPrefix(strings.ToLower(person.Canonical), prefix) {
+			return 2 * person.Messages
+		}
+		return person.Messages
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		sa, sb := score(candidates[a]), score(candidates[b])
+		if sa != sb {
+			return sa > sb
+		}
+		return idx.people[candidates[a]].Address < idx.people[candidates[b]].Address
+	})
+
+	if len(candidates) > MaxCompletions {
+		candidates = candidates[:MaxCompletions]
+	}
+	n.top = candidates
+}
+
+// Complete returns up to limit people with a key starting with prefix,
+// ranked by message volume. People whose address or full name starts with
+// the prefix rank above those matched on a later word of their name. At
+// most MaxCompletions people are returned.
+func (idx *Index) Complete(prefix string, limit int) []Person {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return []Person{}
+	}
+
+	n := idx.root
+	for _, r := range prefix {
+		n = n.children[r]
+		if n == nil {
+			return []Person{}
+		}
+	}
+
+	matches := n.top
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]Person, len(matches))
+	for i, person := range matches {
+		result[i] = idx.people[person]
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package autocomplete
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func addresses(people []Person) []string {
+	result := make([]string, len(people))
+	for i, person := range people {
+		result[i] = person.Address
+	}
+	return result
+}
+
+func TestComplete(t *testing.T) {
+	idx := NewIndex([]Person{
+		{Address: "kenneth.lay@enron.com", Name: "Lay, Kenneth", Messages: 40},
+		{Address: "jeff.skilling@enron.com", Name: "Skilling, Jeff", Messages: 100},
+		{Address: "kevin.presto@enron.com", Name: "Kevin M Presto", Messages: 30},
+		{Address: "sherri.sera@enron.com", Name: "Sera, Sherri", Canonical: "Sherri Kenneth Sera", Messages: 50},
+	})
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		// A match on the address or the full name counts twice
+		{"ke", 10, []string{"kenneth.lay@enron.com", "kevin.presto@enron.com", "sherri.sera@enron.com"}},
+		{"ke", 1, []string{"kenneth.lay@enron.com"}},
+		{"Skil", 10, []string{"jeff.skilling@enron.com"}},
+		{"presto", 10, []string{"kevin.presto@enron.com"}},
+		{"lay, k", 10, []string{"kenneth.lay@enron.com"}},
+		{"x", 10, []string{}},
+		{"  ", 10, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			if got := addresses(idx.Complete(test.prefix, test.limit)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+// complete ranks the people matching prefix by scanning all of them
+func complete(people []Person, prefix string, limit int) []string {
+	var matches []Person
+	for _, person := range people {
+		keys := []string{person.Address, strings.ToLower(person.Name)}
+		keys = append(keys, strings.Fields(strings.ToLower(person.Name))...)
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				matches = append(matches, person)
+				break
+			}
+		}
+	}
+	score := func(person Person) int {
+		if strings.HasPrefix(person.Address, prefix) || strings.HasPrefix(strings.ToLower(person.Name), prefix) {
+			return 2 * person.Messages
+		}
+		return person.Messages
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if sa, sb := score(matches[a]), score(matches[b]); sa != sb {
+			return sa > sb
+		}
+		return matches[a].Address < matches[b].Address
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return addresses(matches)
+}
+
+func TestCompleteKeepsTheBestRanked(t *testing.T) {
+	first := []string{"ken", "kevin", "kate", "jeff", "john", "sherri"}
+	last := []string{"lay", "skilling", "kean", "kitchen", "shankman"}
+	var people []Person
+	for i := 0; i < 3*MaxCompletions; i++ {
+		f, l := first[i%len(first)], last[i%len(last)]
+		people = append(people, Person{
+			Address:  fmt.Sprintf("%s.%s%d@enron.com", f, l, i),
+			Name:     fmt.Sprintf("%s %s", f, l),
+			Messages: (i * 37) % 101,
+		})
+	}
+	idx := NewIndex(people)
+
+	for _, prefix := range []string{"k", "ke", "ken", "kea", "s", "sh", "j", "jo", "ki"} {
+		want := complete(people, prefix, MaxCompletions)
+		if got := addresses(idx.Complete(prefix, MaxCompletions)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", prefix, want, got)
+		}
+	}
+}
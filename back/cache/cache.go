@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// Cache is a concurrency safe in-memory key/value store whose entries
+// expire after a fixed time to live
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[K]entry[V]
+	stats   Stats
+}
+
+// Stats counts the lookups of a cache
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// New creates a new Cache. A maxSize of 0 means no size limit.
+func New[K comparable, V any](ttl time.Duration, maxSize int) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[K]entry[V]),
+	}
+}
+
+// Get returns the value stored for key if it exists and has not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Stats returns the number of hits and misses since the cache was created
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Set stores value for key, evicting expired entries first when the cache is full
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxSize > 0 && len(c.entries) >= c.maxSize {
+		c.evict()
+	}
+	c.entries[key] = entry[V]{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// evict removes expired entries, or an arbitrary one if none has expired.
+// The caller must hold the lock.
+func (c *Cache[K, V]) evict() {
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) < c.maxSize {
+		return
+	}
+	for k := range c.entries {
+		delete(c.entries, k)
+		return
+	}
+}
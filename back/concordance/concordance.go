@@ -0,0 +1,165 @@
+package concordance
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Line is a single occurrence of a term with its surrounding words
+type Line struct {
+	Left  string `json:"left"`
+	Match string `json:"match"`
+	Right string `json:"right"`
+}
+
+// token is a word and its byte offsets in the original text
+type token struct {
+	word       string
+	start, end int
+}
+
+// Tokenize splits text into lower-cased words. Apostrophes and hyphens
+// inside a word are kept so that terms like "mark-to-market" stay whole.
+func Tokenize(text string) []string {
+	tokens := tokenize(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.word
+	}
+	return words
+}
+
+// Word is a lower-cased word of a text and its byte offsets in it
+type Word struct {
+	Text       string
+	Start, End int
+}
+
+// Words splits text like Tokenize but keeps where every word was found
+func Words(text string) []Word {
+	tokens := tokenize(text)
+	words := make([]Word, len(tokens))
+	for i, t := range tokens {
+		words[i] = Word{Text: t.word, Start: t.start, End: t.end}
+	}
+	return words
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) ||
+			(start >= 0 && (r == '-' || r == '\'') && i+1 < len(text) && isWordByte(text[i+1]))
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+func isWordByte(b byte) bool {
+	return b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+// Find returns every occurrence of term in text, with up to width words of
+// context on each side. Matching is case insensitive and term may span
+// several words.
+func Find(text, term string, width int) []Line {
+	needle := Tokenize(term)
+	if len(needle) == 0 {
+		return nil
+	}
+
+	tokens := tokenize(text)
+	var lines []Line
+	for i := 0; i+len(needle) <= len(tokens); i++ {
+		if !matchAt(tokens, i, needle) {
+			continue
+		}
+		last := i + len(needle) - 1
+
+		left := max(0, i-width)
+		right := min(len(tokens)-1, last+width)
+		lines = append(lines, Line{
+			Left:  joinWords(text, tokens[left:i]),
+			Match: text[tokens[i].start:tokens[last].end],
+			Right: joinWords(text, tokens[last+1:right+1]),
+		})
+	}
+	return lines
+}
+
+func matchAt(tokens []token, i int, needle []string) bool {
+	for j, word := range needle {
+		if tokens[i+j].word != word {
+			return false
+		}
+	}
+	return true
+}
+
+// joinWords returns the original spelling of tokens separated by single spaces
+func joinWords(text string, tokens []token) string {
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = text[t.start:t.end]
+	}
+	return strings.Join(words, " ")
+}
+
+// Occurrence is a line of the concordance together with the email it was found in
+type Occurrence struct {
+	ID        string    `json:"id"`
+	MessageID string    `json:"message_id"`
+	Date      time.Time `json:"date"`
+	Line
+}
+
+// Sort orders occurrences by their left context, read from the match
+// outwards, or by their right context. Ties keep their original order.
+func Sort(occurrences []Occurrence, by string) {
+	keys := make([]string, len(occurrences))
+	for i, o := range occurrences {
+		keys[i] = sortKey(o.Line, by)
+	}
+
+	indexes := make([]int, len(occurrences))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return keys[indexes[a]] < keys[indexes[b]]
+	})
+
+	sorted := make([]Occurrence, len(occurrences))
+	for i, index := range indexes {
+		sorted[i] = occurrences[index]
+	}
+	copy(occurrences, sorted)
+}
+
+func sortKey(line Line, by string) string {
+	if by != SortLeft {
+		return strings.ToLower(line.Right)
+	}
+	words := strings.Fields(strings.ToLower(line.Left))
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+	return strings.Join(words, " ")
+}
+
+// Sort orders
+const (
+	SortLeft  = "left"
+	SortRight = "right"
+)
@@ -0,0 +1,114 @@
+package concordance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Book it Mark-to-Market.", []string{"book", "it", "mark-to-market"}},
+		{"Don't ship -- now", []string{"don't", "ship", "now"}},
+		{"trailing- and 'quoted'", []string{"trailing", "and", "quoted"}},
+		{"Q3 2001", []string{"q3", "2001"}},
+		{"  ", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Tokenize(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	text := "The Raptor deal closed. Andy said the raptor\nstructures were fine, RAPTOR."
+
+	tests := []struct {
+		name  string
+		term  string
+		width int
+		want  []Line
+	}{
+		{
+			name:  "every occurrence with its original spelling",
+			term:  "raptor",
+			width: 2,
+			want: []Line{
+				{Left: "The", Match: "Raptor", Right: "deal closed"},
+				{Left: "said the", Match: "raptor", Right: "structures were"},
+				{Left: "were fine", Match: "RAPTOR", Right: ""},
+			},
+		},
+		{
+			name:  "phrase across a line break",
+			term:  "Raptor Structures",
+			width: 1,
+			want:  []Line{{Left: "the", Match: "raptor\nstructures", Right: "were"}},
+		},
+		{name: "missing term", term: "citibank", width: 2},
+		{name: "empty term", term: " - ", width: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Find(text, test.term, test.width); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	lines := []Line{
+		{Left: "a big", Match: "deal", Right: "closed today"},
+		{Left: "the Small", Match: "deal", Right: "Broke down"},
+		{Left: "a small", Match: "deal", Right: "closed early"},
+		{Left: "", Match: "deal", Right: ""},
+		{Left: "another big", Match: "deal", Right: "closed today"},
+	}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		// The word next to the match is compared first, then the one before
+		// it, and ties keep their order
+		{SortLeft, []string{"", "a big", "another big", "a small", "the Small"}},
+		{SortRight, []string{"", "Broke down", "closed early", "closed today", "closed today"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.by, func(t *testing.T) {
+			occurrences := make([]Occurrence, len(lines))
+			for i, line := range lines {
+				occurrences[i] = Occurrence{ID: string(rune('a' + i)), Line: line}
+			}
+			Sort(occurrences, test.by)
+
+			got := make([]string, len(occurrences))
+			for i, o := range occurrences {
+				if test.by == SortLeft {
+					got[i] = o.Left
+				} else {
+					got[i] = o.Right
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	// Lines with the same right context stay in their original order
+	occurrences := []Occurrence{{ID: "first", Line: lines[0]}, {ID: "second", Line: lines[4]}}
+	Sort(occurrences, SortRight)
+	if occurrences[0].ID != "first" || occurrences[1].ID != "second" {
+		t.Errorf("expected ties to keep their order, got %s then %s", occurrences[0].ID, occurrences[1].ID)
+	}
+}
@@ -0,0 +1,41 @@
+package concordance
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent English words that carry no meaning on their own
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about above after again against all am an and any are as at be
+		because been before being below between both but by can could did do does doing down during each
+		few for from further had has have having he her here hers herself him himself his how i if in into
+		is it its itself just me more most my myself no nor not now of off on once only or other our ours
+		ourselves out over own re same she should so some such than that the their theirs them themselves
+		then there these they this those through to too under until up very was we were what when where
+		which while who whom why will with would you your yours yourself yourselves`) {
+		stopwords[word] = true
+	}
+}
+
+// IsStopword reports whether word is a frequent English word that carries
+// no meaning on its own
+func IsStopword(word string) bool {
+	return stopwords[word]
+}
+
+// IsIndexable reports whether word is worth keeping statistics for. Stop
+// words, single characters and plain numbers are skipped.
+func IsIndexable(word string) bool {
+	if len(word) < 2 || stopwords[word] {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Index holds one registry document per dataset, written by
+// `load-data --dataset`
+const Index = "enron_datasets"
+
+// DefaultTTL is how long the registry is cached. A dataset registered by
+// the loader becomes searchable within this delay.
+const DefaultTTL = 30 * time.Second
+
+// MaxDatasets is the maximum number of registry documents read
+const MaxDatasets = 1000
+
+// Default is the dataset served by the routes without a dataset, it is
+// listed even when the registry has no document for it
+var Default = models.Dataset{
+	ID:                 "enron",
+	Name:               "Enron",
+	Index:              "enron_emails",
+	PeopleMetricsIndex: "enron_people_metrics",
+}
+
+// ErrNotFound is returned for a dataset missing from the registry
+var ErrNotFound = errors.New("dataset not found")
+
+// IsValidID reports whether id can name a dataset. Ids are used in URLs
+// and index names, so they follow the rules of index names.
+func IsValidID(id string) bool {
+	return db.IsValidIndexName(id)
+}
+
+// EmailsIndex returns the name the emails of a dataset are read from, as
+// written by `load-data --dataset`. It can be an alias of a versioned index.
+func EmailsIndex(id string) string {
+	return id + "_emails"
+}
+
+// MetricsIndex returns the name `load-data analyze --dataset` writes the
+// network metrics of a dataset to
+func MetricsIndex(id string) string {
+	return id + "_people_metrics"
+}
+
+// FromEmailsIndex returns the dataset whose emails are in the named index,
+// reporting whether the name is one returned by EmailsIndex
+func FromEmailsIndex(name string) (models.Dataset, bool) {
+	id, ok := strings.CutSuffix(name, EmailsIndex(""))
+	if !ok || !IsValidID(id) {
+		return models.Dataset{}, false
+	}
+	return models.Dataset{ID: id, Name: id, Index: name}, true
+}
+
+// Registry lists the datasets stored in the registry index
+type Registry struct {
+	// Client reads the registry index. Without it only Default and Fixed
+	// are listed, as for the local backend which has no registry.
+	Client *db.ZincClient
+	// Index holds the registry documents, Index by default
+	Index string
+	// Default is listed even without a registry document, Default by default
+	Default models.Dataset
+	// Fixed lists datasets known without a registry document
+	Fixed []models.Dataset
+	cache *cache.Cache[string, []models.Dataset]
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry(client *db.ZincClient, ttl time.Duration) *Registry {
+	return &Registry{
+		Client:  client,
+		Index:   Index,
+		Default: Default,
+		cache:   cache.New[string, []models.Dataset](ttl, 0),
+	}
+}
+
+// CacheStats returns the hits and misses of the registry cache
+func (r *Registry) CacheStats() cache.Stats {
+	return r.cache.Stats()
+}
+
+// List returns every registered dataset sorted by id, including the
+// default one. The slice is shared with other callers and must not be
+// modified.
+func (r *Registry) List(ctx context.Context) ([]models.Dataset, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if datasets, ok := r.cache.Get(r.Index); ok {
+		return datasets, nil
+	}
+
+	datasets, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.Set(r.Index, datasets)
+	return datasets, nil
+}
+
+// Get returns the dataset with the given id
+func (r *Registry) Get(ctx context.Context, id string) (models.Dataset, error) {
+	datasets, err := r.List(ctx)
+	if err != nil {
+		return models.Dataset{}, err
+	}
+	for _, dataset := range datasets {
+		if dataset.ID == id {
+			return dataset, nil
+		}
+	}
+	return models.Dataset{}, ErrNotFound
+}
+
+// load reads the registry documents from Zinc
+func (r *Registry) load(ctx context.Context) ([]models.Dataset, error) {
+	byID := map[string]models.Dataset{r.Default.ID: r.Default}
+	for _, dataset := range r.Fixed {
+		byID[dataset.ID] = dataset
+	}
+	if r.Client == nil {
+		return sorted(byID), nil
+	}
+
+	body, err := r.Client.ESSearch(ctx, r.Index, db.ESQuery{
+		"query": db.ESQuery{"match_all": db.ESQuery{}},
+		"size":  MaxDatasets,
+	})
+	switch {
+	case db.IsNotFound(err):
+		// Nothing registered yet, only the default dataset exists
+	case err != nil:
+		return nil, fmt.Errorf("failed to read dataset registry: %w", err)
+	default:
+		var result struct {
+			Hits struct {
+				Hits []struct {
+					Source models.Dataset `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse dataset registry: %w", err)
+		}
+		for _, hit := range result.Hits.Hits {
+			if hit.Source.ID == "" || hit.Source.Index == "" {
+				continue
+			}
+			byID[hit.Source.ID] = hit.Source
+		}
+	}
+	return sorted(byID), nil
+}
+
+// sorted returns the datasets sorted by id
+func sorted(byID map[string]models.Dataset) []models.Dataset {
+	datasets := make([]models.Dataset, 0, len(byID))
+	for _, dataset := range byID {
+		datasets = append(datasets, dataset)
+	}
+	sort.Slice(datasets, func(i, j int) bool { return datasets[i].ID < datasets[j].ID })
+	return datasets
+}
+
+type contextKey struct{}
+
+// WithDataset returns a copy of ctx that carries the dataset of a request
+func WithDataset(ctx context.Context, dataset models.Dataset) context.Context {
+	return context.WithValue(ctx, contextKey{}, dataset)
+}
+
+// FromContext returns the dataset of a request, or the default dataset
+// when the route did not select one
+func FromContext(ctx context.Context) models.Dataset {
+	if dataset, ok := ctx.Value(contextKey{}).(models.Dataset); ok {
+		return dataset
+	}
+	return Default
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting Zinc while the circuit
+// breaker considers it unhealthy
+var ErrCircuitOpen = errors.New("zinc is unavailable, circuit breaker is open")
+
+// IsUnavailable reports whether err means Zinc can't serve requests right
+// now, so that handlers answer 503 instead of 500
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// breaker is a circuit breaker. It opens after a number of consecutive
+// failures and rejects calls until a cooldown has passed, then lets a
+// single trial call through: the circuit closes again if it succeeds.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may be sent
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record updates the breaker with the outcome of a call
+func (b *breaker) record(success bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a call without an outcome, such as a call canceled by the
+// caller, letting another trial call through
+func (b *breaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,65 @@
+package db
+
+import "time"
+
+// ESQuery is a fragment of an Elasticsearch compatible query body
+type ESQuery = map[string]interface{}
+
+// MatchPhrase matches documents whose field contains value as a phrase.
+// It works for both analyzed text and keyword fields.
+func MatchPhrase(field, value string) ESQuery {
+	return ESQuery{"match_phrase": ESQuery{field: value}}
+}
+
+// Wildcard matches documents whose keyword field holds a value matching
+// pattern, where * stands for any run of characters and ? for one
+func Wildcard(field, pattern string) ESQuery {
+	return ESQuery{"wildcard": ESQuery{field: pattern}}
+}
+
+// AnyOf matches documents that satisfy at least one of the queries
+func AnyOf(queries ...ESQuery) ESQuery {
+	return ESQuery{"bool": ESQuery{
+		"should":               queries,
+		"minimum_should_match": 1,
+	}}
+}
+
+// AllOf matches documents that satisfy every one of the queries
+func AllOf(queries ...ESQuery) ESQuery {
+	return ESQuery{"bool": ESQuery{"must": queries}}
+}
+
+// AllOfExcept matches documents that satisfy every one of the queries and
+// none of the excluded ones
+func AllOfExcept(queries []ESQuery, excluded ...ESQuery) ESQuery {
+	return ESQuery{"bool": ESQuery{"must": queries, "must_not": excluded}}
+}
+
+// DateRange matches documents whose field falls between start and end. A
+// zero time leaves that side of the range open.
+func DateRange(field string, start, end time.Time) ESQuery {
+	bounds := ESQuery{}
+	if !start.IsZero() {
+		bounds["gte"] = start.Format(time.RFC3339)
+	}
+	if !end.IsZero() {
+		bounds["lte"] = end.Format(time.RFC3339)
+	}
+	return ESQuery{"range": ESQuery{field: bounds}}
+}
+
+// TermsAgg buckets documents by the size most frequent values of field
+func TermsAgg(field string, size int) ESQuery {
+	return ESQuery{"terms": ESQuery{"field": field, "size": size}}
+}
+
+// DateHistogramAgg buckets documents by calendar interval of a date field
+func DateHistogramAgg(field, interval string) ESQuery {
+	return ESQuery{"date_histogram": ESQuery{"field": field, "calendar_interval": interval}}
+}
+
+// MetricAgg computes a single value metric such as min or max over field
+func MetricAgg(kind, field string) ESQuery {
+	return ESQuery{kind: ESQuery{"field": field}}
+}
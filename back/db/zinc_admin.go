@@ -0,0 +1,257 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// listPageSize is the number of indexes requested per page by ListIndexes
+const listPageSize = 100
+
+var validIndexName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// IsValidIndexName reports whether name can name an index: lowercase
+// letters, digits, dashes and underscores, starting with a letter or digit
+func IsValidIndexName(name string) bool {
+	return validIndexName.MatchString(name)
+}
+
+// Analyzer is a custom analyzer built from a tokenizer and token filters
+type Analyzer struct {
+	Tokenizer   string   `json:"tokenizer"`
+	TokenFilter []string `json:"token_filter"`
+}
+
+// Analysis holds the custom analyzers of an index
+type Analysis struct {
+	Analyzer map[string]Analyzer `json:"analyzer"`
+}
+
+// IndexSettings holds the settings of an index
+type IndexSettings struct {
+	Analysis Analysis `json:"analysis"`
+}
+
+// IndexDefinition is the body of the create index request
+type IndexDefinition struct {
+	Name        string        `json:"name"`
+	StorageType string        `json:"storage_type"`
+	ShardNum    int           `json:"shard_num"`
+	Settings    IndexSettings `json:"settings"`
+	Mappings    Mappings      `json:"mappings"`
+}
+
+// IndexStats holds the statistics Zinc keeps about an index. DocTimeMin
+// and DocTimeMax are the ingest times of the oldest and newest documents
+// in Unix microseconds.
+type IndexStats struct {
+	DocNum      int   `json:"doc_num"`
+	StorageSize int   `json:"storage_size"`
+	DocTimeMin  int64 `json:"doc_time_min"`
+	DocTimeMax  int64 `json:"doc_time_max"`
+}
+
+// IndexInfo describes an existing index
+type IndexInfo struct {
+	Name        string     `json:"name"`
+	StorageType string     `json:"storage_type"`
+	ShardNum    int        `json:"shard_num"`
+	Mappings    Mappings   `json:"mappings"`
+	Stats       IndexStats `json:"stats"`
+}
+
+// CreateIndex creates an index from its definition
+func (zc *ZincClient) CreateIndex(ctx context.Context, def IndexDefinition) error {
+	body, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index %s: %w", def.Name, err)
+	}
+	if _, err := zc.do(ctx, "create_index", "POST", "/api/index", body); err != nil {
+		return fmt.Errorf("failed to create index %s: %w", def.Name, err)
+	}
+	return nil
+}
+
+// DeleteIndex deletes an index and all its documents
+func (zc *ZincClient) DeleteIndex(ctx context.Context, name string) error {
+	if _, err := zc.do(ctx, "delete_index", "DELETE", "/api/index/"+url.PathEscape(name), nil); err != nil {
+		return fmt.Errorf("failed to delete index %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetIndex returns the description of an index
+func (zc *ZincClient) GetIndex(ctx context.Context, name string) (*IndexInfo, error) {
+	body, err := zc.do(ctx, "get_index", "GET", "/api/index/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index %s: %w", name, err)
+	}
+
+	var info IndexInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse index %s: %w", name, err)
+	}
+	return &info, nil
+}
+
+// IndexExists reports whether an index exists
+func (zc *ZincClient) IndexExists(ctx context.Context, name string) (bool, error) {
+	_, err := zc.GetIndex(ctx, name)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListIndexes returns every index with its document count and storage size
+func (zc *ZincClient) ListIndexes(ctx context.Context) ([]IndexInfo, error) {
+	var indexes []IndexInfo
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("/api/index?page_num=%d&page_size=%d", page, listPageSize)
+		body, err := zc.do(ctx, "list_indexes", "GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list indexes: %w", err)
+		}
+
+		// Zinc versions before 0.4 answer with a plain array of every index
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &indexes); err != nil {
+				return nil, fmt.Errorf("failed to parse indexes: %w", err)
+			}
+			return indexes, nil
+		}
+
+		var response struct {
+			List []IndexInfo `json:"list"`
+			Page struct {
+				Total int `json:"total"`
+			} `json:"page"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse indexes: %w", err)
+		}
+		indexes = append(indexes, response.List...)
+		if len(response.List) == 0 || len(indexes) >= response.Page.Total {
+			return indexes, nil
+		}
+	}
+}
+
+// PutMapping sets the field mapping of an index, creating the index if it
+// does not exist. Zinc rejects changes to the type of a field that already
+// holds documents.
+func (zc *ZincClient) PutMapping(ctx context.Context, index string, mappings Mappings) error {
+	body, err := json.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mapping of %s: %w", index, err)
+	}
+	if _, err := zc.do(ctx, "put_mapping", "PUT", fmt.Sprintf("/api/%s/_mapping", url.PathEscape(index)), body); err != nil {
+		return fmt.Errorf("failed to put mapping of %s: %w", index, err)
+	}
+	return nil
+}
+
+// PutDocument creates or replaces the document with the given id
+func (zc *ZincClient) PutDocument(ctx context.Context, index, id string, doc interface{}) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document %s: %w", id, err)
+	}
+	if _, err := zc.do(ctx, "put_document", "PUT", fmt.Sprintf("/api/%s/_doc/%s", url.PathEscape(index), url.PathEscape(id)), body); err != nil {
+		return fmt.Errorf("failed to put document %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteDocument deletes the document with the given id
+func (zc *ZincClient) DeleteDocument(ctx context.Context, index, id string) error {
+	if _, err := zc.do(ctx, "delete_document", "DELETE", fmt.Sprintf("/api/%s/_doc/%s", url.PathEscape(index), url.PathEscape(id)), nil); err != nil {
+		return fmt.Errorf("failed to delete document %s: %w", id, err)
+	}
+	return nil
+}
+
+// Bulk operation actions
+const (
+	BulkIndex  = "index"
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkOperation is a single document operation of a bulk request. ID may
+// be empty when indexing or creating, Zinc then generates one. Doc is
+// ignored when deleting.
+type BulkOperation struct {
+	Action string
+	ID     string
+	Doc    interface{}
+}
+
+// bulkResponse is the answer of both bulk APIs
+type bulkResponse struct {
+	RecordCount int    `json:"record_count"`
+	Error       string `json:"error"`
+}
+
+// Bulk sends operations on documents of an index through the Elasticsearch
+// compatible bulk API and returns the number of records Zinc processed
+func (zc *ZincClient) Bulk(ctx context.Context, index string, operations []BulkOperation) (int, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, op := range operations {
+		meta := map[string]string{"_index": index}
+		if op.ID != "" {
+			meta["_id"] = op.ID
+		}
+		if err := enc.Encode(map[string]interface{}{op.Action: meta}); err != nil {
+			return 0, fmt.Errorf("failed to encode bulk operation: %w", err)
+		}
+		if op.Action == BulkDelete {
+			continue
+		}
+		if err := enc.Encode(op.Doc); err != nil {
+			return 0, fmt.Errorf("failed to encode bulk document: %w", err)
+		}
+	}
+
+	body, err := zc.do(ctx, "bulk", "POST", "/api/_bulk", buf.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk index into %s: %w", index, err)
+	}
+	return parseBulkResponse(index, body)
+}
+
+// BulkV2 indexes records through the bulkv2 API, a single JSON document
+// rather than the newline delimited operations of Bulk, and returns the
+// number of records Zinc processed
+func (zc *ZincClient) BulkV2(ctx context.Context, index string, records interface{}) (int, error) {
+	body, err := json.Marshal(struct {
+		Index   string      `json:"index"`
+		Records interface{} `json:"records"`
+	}{index, records})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal records: %w", err)
+	}
+
+	body, err = zc.do(ctx, "bulkv2", "POST", "/api/_bulkv2", body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk index into %s: %w", index, err)
+	}
+	return parseBulkResponse(index, body)
+}
+
+func parseBulkResponse(index string, body []byte) (int, error) {
+	var response bulkResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("failed to parse bulk response of %s: %w", index, err)
+	}
+	if response.Error != "" {
+		return response.RecordCount, fmt.Errorf("bulk index into %s failed: %s", index, response.Error)
+	}
+	return response.RecordCount, nil
+}
@@ -2,51 +2,125 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
 )
 
+// tracer creates the spans of the requests sent to Zinc
+var tracer = otel.Tracer(tracing.Name + "/db")
+
 type ZincClient struct {
 	baseURL    string
 	username   string
 	password   string
 	httpClient *http.Client
+	options    Options
+	breaker    *breaker
 }
 
-// NewZincClient creates a new instance of ZincClient
+// Options tunes the timeouts, retries and circuit breaker of a client
+type Options struct {
+	// Timeout bounds every attempt of a request
+	Timeout time.Duration
+	// MaxRetries is the number of times an idempotent request is retried
+	// after a network error or an overloaded Zinc
+	MaxRetries int
+	// BackoffBase and BackoffMax bound the jittered exponential delay
+	// between two attempts
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// BreakerThreshold is the number of consecutive failed requests that
+	// open the circuit breaker, zero disables it
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before letting a
+	// trial request through
+	BreakerCooldown time.Duration
+	// Observe is called after every request with its outcome, for metrics
+	Observe func(Call)
+}
+
+// Call describes a finished request to Zinc
+type Call struct {
+	// Operation names the client method, such as search or bulkv2
+	Operation string
+	Duration  time.Duration
+	// Attempts is the number of times the request was sent, zero when the
+	// circuit breaker rejected it
+	Attempts int
+	// BytesSent is the size of the request body, sent once per attempt
+	BytesSent int
+	Err       error
+}
+
+// DefaultOptions returns the options used by NewZincClient
+func DefaultOptions() Options {
+	return Options{
+		Timeout:          10 * time.Second,
+		MaxRetries:       2,
+		BackoffBase:      100 * time.Millisecond,
+		BackoffMax:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// NewZincClient creates a new instance of ZincClient with the default options
 func NewZincClient(baseURL, username, password string) *ZincClient {
+	return NewZincClientWithOptions(baseURL, username, password, DefaultOptions())
+}
+
+// NewZincClientWithOptions creates a new instance of ZincClient
+func NewZincClientWithOptions(baseURL, username, password string, options Options) *ZincClient {
 	return &ZincClient{
 		baseURL:    baseURL,
 		username:   username,
 		password:   password,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{},
+		options:    options,
+		breaker:    newBreaker(options.BreakerThreshold, options.BreakerCooldown),
 	}
 }
 
-func (zc *ZincClient) newRequest(method, endpoint string, body []byte) (*http.Request, error) {
+func (zc *ZincClient) newRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Request, error) {
 	url := zc.baseURL + endpoint
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	req.SetBasicAuth(zc.username, zc.password)
 	req.Header.Set("Content-Type", "application/json")
+	// Carry the trace context, so that Zinc can join the trace
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
 	return req, nil
 }
 
-func (zc *ZincClient) Ping() error {
-	req, err := zc.newRequest("GET", "/", nil)
-	if err != nil {
-		return err
-	}
-	resp, err := zc.httpClient.Get(req.URL.String())
-	if err != nil {
+// Ping checks that Zinc answers an authenticated request. The web UI at the
+// root answers without credentials, so a single index is listed instead.
+func (zc *ZincClient) Ping(ctx context.Context) error {
+	if _, err := zc.do(ctx, "ping", "GET", "/api/index?page_num=1&page_size=1", nil); err != nil {
 		return fmt.Errorf("ping failed: %w", err)
 	}
-	defer resp.Body.Close()
 	return nil
 }
 
@@ -69,7 +143,7 @@ type Query struct {
 
 // Search sends a search request to the Zinc database
 // Search sends a search request to the Zinc database
-func (zc *ZincClient) Search(index, term, searchType, field string, from, maxResults int, sourceFields, sortFields []string) ([]byte, error) {
+func (zc *ZincClient) Search(ctx context.Context, index, term, searchType, field string, from, maxResults int, sourceFields, sortFields []string) ([]byte, error) {
 	// Prepare the search request body
 	searchRequest := SearchRequest{
 		SearchType: searchType,
@@ -91,29 +165,249 @@ func (zc *ZincClient) Search(index, term, searchType, field string, from, maxRes
 	// Construct the endpoint for the search request
 	endpoint := fmt.Sprintf("/api/%s/_search", index)
 
+	return zc.do(ctx, "search", "POST", endpoint, body)
+}
+
+// ESSearch sends a raw Elasticsearch-compatible query to the Zinc database.
+// Unlike Search it supports aggregations and compound queries.
+func (zc *ZincClient) ESSearch(ctx context.Context, index string, query interface{}) ([]byte, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal es search request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/es/%s/_search", index)
+	return zc.do(ctx, "es_search", "POST", endpoint, body)
+}
+
+// do sends a request to the Zinc database and returns the body of a 200
+// response. Idempotent requests are retried with a jittered exponential
+// backoff while Zinc is unreachable or overloaded.
+func (zc *ZincClient) do(ctx context.Context, operation, method, endpoint string, body []byte) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "zinc."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(strings.SplitN(endpoint, "?", 2)[0]),
+		))
+
+	call := Call{Operation: operation, BytesSent: len(body)}
+	start := time.Now()
+	responseBody, err := zc.send(ctx, method, endpoint, body, &call)
+
+	span.SetAttributes(
+		attribute.Int("zinc.attempts", call.Attempts),
+		attribute.Int("zinc.request_bytes", len(body)),
+		attribute.Int("zinc.response_bytes", len(responseBody)),
+	)
+	tracing.End(span, err)
+
+	duration := time.Since(start)
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.Int("attempts", call.Attempts),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	}
+	level := slog.LevelDebug
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		// A missing index or document is an answer, not a failure
+		if !IsNotFound(err) {
+			level = slog.LevelWarn
+		}
+	}
+	slog.LogAttrs(ctx, level, "zinc request", attrs...)
+
+	if zc.options.Observe != nil {
+		call.Duration = duration
+		call.Err = err
+		zc.options.Observe(call)
+	}
+	return responseBody, err
+}
+
+// send runs the attempts of a request, counting them in call
+func (zc *ZincClient) send(ctx context.Context, method, endpoint string, body []byte, call *Call) ([]byte, error) {
+	if !zc.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	retries := 0
+	if isIdempotent(method, endpoint) {
+		retries = zc.options.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		call.Attempts++
+		responseBody, err := zc.attempt(ctx, method, endpoint, body)
+		switch {
+		case ctx.Err() != nil:
+			// A request given up by the caller says nothing about Zinc
+			zc.breaker.release()
+			return nil, ctx.Err()
+		case err == nil || !isServerFailure(err):
+			zc.breaker.record(true)
+			return responseBody, err
+		case attempt >= retries:
+			zc.breaker.record(false)
+			return nil, err
+		}
+
+		delay := zc.backoff(attempt)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+			attribute.String("backoff", delay.String()),
+		))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			zc.breaker.release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// attempt sends a request once
+func (zc *ZincClient) attempt(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	if zc.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, zc.options.Timeout)
+		defer cancel()
+	}
+
 	// Use the newRequest method to create the HTTP request
-	req, err := zc.newRequest("POST", endpoint, body)
+	req, err := zc.newRequest(ctx, method, endpoint, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create search request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Send the request using the HTTP client
 	resp, err := zc.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send search request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the response body
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read search response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check for non-200 status codes
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("search request failed with status %d: %s", resp.StatusCode, responseBody)
+		return nil, &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	return responseBody, nil
 }
+
+// backoff returns the delay before the retry following attempt, drawn at
+// random up to an exponentially growing bound so that clients retrying
+// together do not hit Zinc at the same time
+func (zc *ZincClient) backoff(attempt int) time.Duration {
+	bound := zc.options.BackoffBase << attempt
+	if bound <= 0 || bound > zc.options.BackoffMax {
+		bound = zc.options.BackoffMax
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(bound)))
+}
+
+// isIdempotent reports whether a request can be sent again safely. Searches
+// are POST requests that do not change anything.
+func isIdempotent(method, endpoint string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return strings.HasSuffix(endpoint, "/_search")
+}
+
+// isServerFailure reports whether err means Zinc is unreachable or
+// struggling, rather than rejecting the request itself
+func isServerFailure(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests ||
+			(statusErr.StatusCode >= 500 && statusErr.StatusCode != http.StatusNotImplemented)
+	}
+	return true
+}
+
+// StatusError is returned when Zinc answers with a non-200 status
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %d: %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is a Zinc answer for a missing index or document
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	// Zinc answers 400 rather than 404 for some missing resources
+	return statusErr.StatusCode == http.StatusNotFound ||
+		(statusErr.StatusCode == http.StatusBadRequest && strings.Contains(statusErr.Body, "does not exist"))
+}
+
+// GetDocument decodes the source of the document with the given id into doc
+func (zc *ZincClient) GetDocument(ctx context.Context, index, id string, doc interface{}) error {
+	body, err := zc.do(ctx, "get_document", "GET", fmt.Sprintf("/api/%s/_doc/%s", index, url.PathEscape(id)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to get document %s: %w", id, err)
+	}
+
+	var hit struct {
+		Source json.RawMessage `json:"_source"`
+	}
+	if err := json.Unmarshal(body, &hit); err != nil {
+		return fmt.Errorf("failed to parse document %s: %w", id, err)
+	}
+	return json.Unmarshal(hit.Source, doc)
+}
+
+// Property is the mapping of a single field of an index
+type Property struct {
+	Type          string `json:"type"`
+	Index         bool   `json:"index"`
+	Store         bool   `json:"store"`
+	Sortable      bool   `json:"sortable"`
+	Aggregatable  bool   `json:"aggregatable"`
+	Highlightable bool   `json:"highlightable"`
+	Analyzer      string `json:"analyzer,omitempty"`
+	Format        string `json:"format,omitempty"`
+}
+
+// Mappings is the field mapping of an index
+type Mappings struct {
+	Properties map[string]Property `json:"properties"`
+}
+
+// GetMapping returns the field mapping of an index
+func (zc *ZincClient) GetMapping(ctx context.Context, index string) (*Mappings, error) {
+	body, err := zc.do(ctx, "get_mapping", "GET", fmt.Sprintf("/api/%s/_mapping", index), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapping of %s: %w", index, err)
+	}
+
+	var response map[string]struct {
+		Mappings Mappings `json:"mappings"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping of %s: %w", index, err)
+	}
+
+	mapping, ok := response[index]
+	if !ok {
+		return nil, fmt.Errorf("index %s has no mapping", index)
+	}
+	return &mapping.Mappings, nil
+}
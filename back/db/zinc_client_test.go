@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testOptions() Options {
+	return Options{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		BackoffBase:      time.Millisecond,
+		BackoffMax:       5 * time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  50 * time.Millisecond,
+	}
+}
+
+func TestSearchRetriesOverloadedZinc(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewZincClientWithOptions(server.URL, "admin", "secret", testOptions())
+	if _, err := client.ESSearch(context.Background(), "emails", map[string]interface{}{}); err != nil {
+		t.Fatalf("search failed after retries: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("got %d attempts, want 3", got)
+	}
+}
+
+func TestBreakerFailsFastAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	options := testOptions()
+	options.MaxRetries = 0
+	client := NewZincClientWithOptions(server.URL, "admin", "secret", options)
+	ctx := context.Background()
+
+	for i := 0; i < options.BreakerThreshold; i++ {
+		if _, err := client.ESSearch(ctx, "emails", nil); err == nil || IsUnavailable(err) {
+			t.Fatalf("call %d: got %v, want a status error", i, err)
+		}
+	}
+
+	before := calls.Load()
+	if _, err := client.ESSearch(ctx, "emails", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != before {
+		t.Fatal("an open breaker contacted Zinc")
+	}
+
+	healthy.Store(true)
+	time.Sleep(options.BreakerCooldown)
+	if _, err := client.ESSearch(ctx, "emails", nil); err != nil {
+		t.Fatalf("trial call failed: %v", err)
+	}
+	if _, err := client.ESSearch(ctx, "emails", nil); err != nil {
+		t.Fatalf("breaker did not close: %v", err)
+	}
+}
+
+func TestPostIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewZincClientWithOptions(server.URL, "admin", "secret", testOptions())
+	if _, err := client.do(context.Background(), "bulkv2", "POST", "/api/_bulkv2", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("got %d attempts, want 1", got)
+	}
+}
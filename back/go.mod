@@ -5,3 +5,44 @@ go 1.23.4
 require github.com/go-chi/chi/v5 v5.2.0
 
 require github.com/go-chi/cors v1.2.1
+
+require github.com/antzucaro/matchr v0.0.0-20221106193745-7bed6ef61ef9
+
+require github.com/prometheus/client_golang v1.20.5
+
+require go.opentelemetry.io/otel v1.32.0
+
+require go.opentelemetry.io/otel/sdk v1.32.0
+
+require go.opentelemetry.io/otel/trace v1.32.0
+
+require go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.32.0
+
+require go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.32.0
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.23.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.32.0 // indirect
+	go.opentelemetry.io/otel/metric v1.32.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.3.1 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/text v0.20.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20241104194629-dd2ea8efbc28 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241104194629-dd2ea8efbc28 // indirect
+	google.golang.org/grpc v1.67.1 // indirect
+	google.golang.org/protobuf v1.35.1 // indirect
+)
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Node is a person in the communication graph
+type Node struct {
+	ID       string `json:"id"`
+	Sent     int    `json:"sent"`
+	Received int    `json:"received"`
+}
+
+// Edge is a directed sender to recipient relation, weighted by the number of
+// messages sent through each recipient header
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	To     int    `json:"to"`
+	Cc     int    `json:"cc"`
+	Bcc    int    `json:"bcc"`
+	Weight int    `json:"weight"`
+}
+
+// Graph is a weighted directed graph of who emailed whom
+type Graph struct {
+	Nodes     []*Node `json:"nodes"`
+	Edges     []*Edge `json:"edges"`
+	Messages  int     `json:"messages"`
+	Truncated bool    `json:"truncated"`
+}
+
+type edgeKey struct {
+	source, target string
+}
+
+// Builder accumulates messages into a Graph
+type Builder struct {
+	nodes    map[string]*Node
+	edges    map[edgeKey]*Edge
+	messages int
+}
+
+// NewBuilder creates an empty graph builder
+func NewBuilder() *Builder {
+	return &Builder{
+		nodes: make(map[string]*Node),
+		edges: make(map[edgeKey]*Edge),
+	}
+}
+
+// Add records a message from sender to its To, Cc and Bcc recipients.
+// Addresses are normalized and a recipient listed in several headers of the
+// same message is only counted once, in the most visible header.
+func (b *Builder) Add(from string, to, cc, bcc []string) {
+	from = normalize(from)
+	if from == "" {
+		return
+	}
+	b.messages++
+	b.node(from).Sent++
+
+	seen := make(map[string]bool)
+	for header, recipients := range [][]string{to, cc, bcc} {
+		for _, recipient := range recipients {
+			recipient = normalize(recipient)
+			if recipient == "" || seen[recipient] {
+				continue
+			}
+			seen[recipient] = true
+			b.node(recipient).Received++
+
+			edge := b.edge(from, recipient)
+			switch header {
+			case 0:
+				edge.To++
+			case 1:
+				edge.Cc++
+			case 2:
+				edge.Bcc++
+			}
+			edge.Weight++
+		}
+	}
+}
+
+// Graph returns the accumulated graph with nodes and edges in a stable order
+func (b *Builder) Graph() *Graph {
+	g := &Graph{
+		Nodes:    make([]*Node, 0, len(b.nodes)),
+		Edges:    make([]*Edge, 0, len(b.edges)),
+		Messages: b.messages,
+	}
+	for _, node := range b.nodes {
+		g.Nodes = append(g.Nodes, node)
+	}
+	for _, edge := range b.edges {
+		g.Edges = append(g.Edges, edge)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+	return g
+}
+
+func (b *Builder) node(id string) *Node {
+	node, ok := b.nodes[id]
+	if !ok {
+		node = &Node{ID: id}
+		b.nodes[id] = node
+	}
+	return node
+}
+
+func (b *Builder) edge(source, target string) *Edge {
+	key := edgeKey{source, target}
+	edge, ok := b.edges[key]
+	if !ok {
+		edge = &Edge{Source: source, Target: target}
+		b.edges[key] = edge
+	}
+	return edge
+}
+
+func normalize(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
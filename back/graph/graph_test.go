@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		add      func(b *Builder)
+		messages int
+		nodes    []Node
+		edges    []Edge
+	}{
+		{
+			name: "headers are counted apart",
+			add: func(b *Builder) {
+				b.Add("jeff.skilling@enron.com", []string{"ken.lay@enron.com"}, []string{"sherri.sera@enron.com"}, nil)
+				b.Add("jeff.skilling@enron.com", nil, []string{"ken.lay@enron.com"}, []string{"sherri.sera@enron.com"})
+			},
+			messages: 2,
+			nodes: []Node{
+				{ID: "jeff.skilling@enron.com", Sent: 2},
+				{ID: "ken.lay@enron.com", Received: 2},
+				{ID: "sherri.sera@enron.com", Received: 2},
+			},
+			edges: []Edge{
+				{Source: "jeff.skilling@enron.com", Target: "ken.lay@enron.com", To: 1, Cc: 1, Weight: 2},
+				{Source: "jeff.skilling@enron.com", Target: "sherri.sera@enron.com", Cc: 1, Bcc: 1, Weight: 2},
+			},
+		},
+		{
+			name: "addresses are normalized",
+			add: func(b *Builder) {
+				b.Add(" Jeff.Skilling@Enron.com", []string{"KEN.LAY@enron.com "}, nil, nil)
+				b.Add("ken.lay@enron.com", []string{"jeff.skilling@enron.com"}, nil, nil)
+			},
+			messages: 2,
+			nodes: []Node{
+				{ID: "jeff.skilling@enron.com", Sent: 1, Received: 1},
+				{ID: "ken.lay@enron.com", Sent: 1, Received: 1},
+			},
+			edges: []Edge{
+				{Source: "jeff.skilling@enron.com", Target: "ken.lay@enron.com", To: 1, Weight: 1},
+				{Source: "ken.lay@enron.com", Target: "jeff.skilling@enron.com", To: 1, Weight: 1},
+			},
+		},
+		{
+			name: "a recipient counts once in the most visible header",
+			add: func(b *Builder) {
+				b.Add("jeff.skilling@enron.com", []string{"ken.lay@enron.com", "Ken.Lay@enron.com"}, []string{"ken.lay@enron.com"}, []string{"ken.lay@enron.com", ""})
+			},
+			messages: 1,
+			nodes: []Node{
+				{ID: "jeff.skilling@enron.com", Sent: 1},
+				{ID: "ken.lay@enron.com", Received: 1},
+			},
+			edges: []Edge{
+				{Source: "jeff.skilling@enron.com", Target: "ken.lay@enron.com", To: 1, Weight: 1},
+			},
+		},
+		{
+			name: "messages without a sender are skipped",
+			add: func(b *Builder) {
+				b.Add("  ", []string{"ken.lay@enron.com"}, nil, nil)
+			},
+			nodes: []Node{},
+			edges: []Edge{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBuilder()
+			test.add(b)
+			g := b.Graph()
+
+			if g.Messages != test.messages {
+				t.Errorf("expected %d messages, got %d", test.messages, g.Messages)
+			}
+			nodes := make([]Node, len(g.Nodes))
+			for i, node := range g.Nodes {
+				nodes[i] = *node
+			}
+			if !reflect.DeepEqual(nodes, test.nodes) {
+				t.Errorf("expected nodes %+v, got %+v", test.nodes, nodes)
+			}
+			edges := make([]Edge, len(g.Edges))
+			for i, edge := range g.Edges {
+				edges[i] = *edge
+			}
+			if !reflect.DeepEqual(edges, test.edges) {
+				t.Errorf("expected edges %+v, got %+v", test.edges, edges)
+			}
+		})
+	}
+}
+
+func TestExports(t *testing.T) {
+	b := NewBuilder()
+	b.Add("jeff.skilling@enron.com", []string{"ken.lay@enron.com"}, []string{"sherri.sera@enron.com"}, nil)
+	g := b.Graph()
+
+	tests := []struct {
+		name  string
+		write func(buf *bytes.Buffer, g *Graph) error
+		root  string
+	}{
+		{"graphml", func(buf *bytes.Buffer, g *Graph) error { return WriteGraphML(buf, g) }, "graphml"},
+		{"gexf", func(buf *bytes.Buffer, g *Graph) error { return WriteGEXF(buf, g) }, "gexf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.write(&buf, g); err != nil {
+				t.Fatal(err)
+			}
+
+			// The document must be well formed and hold every node and edge
+			var doc struct {
+				XMLName xml.Name
+			}
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("invalid XML: %v", err)
+			}
+			if doc.XMLName.Local != test.root {
+				t.Errorf("expected a %s document, got %s", test.root, doc.XMLName.Local)
+			}
+			out := buf.String()
+			for _, node := range g.Nodes {
+				if !strings.Contains(out, `"`+node.ID+`"`) {
+					t.Errorf("expected node %s in the export", node.ID)
+				}
+			}
+			if n := strings.Count(out, "<edge "); n != len(g.Edges) {
+				t.Errorf("expected %d edges, got %d", len(g.Edges), n)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DanielOsorio01/enron-email-search/back/autocomplete"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+type Autocomplete struct {
+	PeopleIndex *autocomplete.People
+}
+
+// People returns the people whose address or name starts with the q parameter
+func (h *Autocomplete) People(w http.ResponseWriter, r *http.Request) {
+	if h.PeopleIndex == nil {
+		sendError(w, "People index is not loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := email.DefaultAutocompleteLimit
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		limit = min(n, email.MaxAutocompleteLimit)
+	}
+
+	response := Response{
+		Success: true,
+		Data:    h.PeopleIndex.Index().Complete(query.Get("q"), limit),
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
+
+// Refresh reloads the people index from disk without restarting the server
+func (h *Autocomplete) Refresh(w http.ResponseWriter, r *http.Request) {
+	if h.PeopleIndex == nil {
+		sendError(w, "People index is not loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.PeopleIndex.Reload(); err != nil {
+		sendError(w, "Failed to reload people index: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"people": h.PeopleIndex.Index().Len(),
+		},
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+// errConcordanceFull stops a scan once enough occurrences have been found
+var errConcordanceFull = errors.New("concordance limit reached")
+
+// Concordance returns every occurrence of the term in the matching emails
+// with its left and right context, as newline delimited JSON. Unsorted
+// results are streamed as the emails are scanned, sorted results are sent
+// once every occurrence has been collected.
+func (h *Email) Concordance(w http.ResponseWriter, r *http.Request) {
+	if !h.requireRepo(w) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	term := query.Get("term")
+	if term == "" {
+		sendError(w, "Search term is required", http.StatusBadRequest)
+		return
+	}
+
+	width := email.DefaultContextWords
+	if value := query.Get("context"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > email.MaxContextWords {
+			sendError(w, "Context must be a number of words between 0 and "+strconv.Itoa(email.MaxContextWords), http.StatusBadRequest)
+			return
+		}
+		width = n
+	}
+
+	limit := email.MaxConcordanceLines
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 && n < limit {
+		limit = n
+	}
+
+	sortBy := query.Get("sort")
+	if sortBy != "" && sortBy != concordance.SortLeft && sortBy != concordance.SortRight {
+		sendError(w, "Sort must be one of left or right", http.StatusBadRequest)
+		return
+	}
+
+	start, err := parseDate(query.Get("start"))
+	if err != nil {
+		sendError(w, "Invalid start date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := parseEndDate(query.Get("end"))
+	if err != nil {
+		sendError(w, "Invalid end date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	var collected []concordance.Occurrence
+	count := 0
+	_, err = h.Repo.Scan(r.Context(), indexOf(r), email.FilterQuery(term, start, end),
+		[]string{"message_id", "date", "body"}, scanLimit(h.MaxScanResults),
+		func(hit email.SearchHitItem) error {
+			for _, line := range concordance.Find(hit.Source.Body, term, width) {
+				occurrence := concordance.Occurrence{
+					ID:        hit.ID,
+					MessageID: hit.Source.MessageID,
+					Date:      hit.Source.Date,
+					Line:      line,
+				}
+				if sortBy != "" {
+					collected = append(collected, occurrence)
+				} else if err := enc.Encode(occurrence); err != nil {
+					return err
+				}
+
+				count++
+				if count >= limit {
+					return errConcordanceFull
+				}
+			}
+			if flusher != nil && sortBy == "" {
+				flusher.Flush()
+			}
+			return nil
+		})
+
+	if sortBy != "" {
+		concordance.Sort(collected, sortBy)
+		for _, occurrence := range collected {
+			if enc.Encode(occurrence) != nil {
+				return
+			}
+		}
+	}
+
+	// Headers are already sent, so report scan failures as a final line
+	if err != nil && !errors.Is(err, errConcordanceFull) {
+		enc.Encode(Response{Success: false, Error: "Failed to scan emails: " + err.Error()})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+type Dataset struct {
+	Registry *dataset.Registry
+	Searcher email.EmailSearcher
+}
+
+// List returns the registered datasets with the number of emails in each
+func (h *Dataset) List(w http.ResponseWriter, r *http.Request) {
+	datasets, err := h.Registry.List(r.Context())
+	if err != nil {
+		sendFailure(w, "Failed to list datasets", err)
+		return
+	}
+
+	// The registry shares its datasets between requests, the counts go to
+	// copies of them
+	params := email.DefaultSearchParams()
+	params.SearchType = email.SearchTypeMatchAll
+	counted := make([]models.Dataset, len(datasets))
+	for i, d := range datasets {
+		total, err := h.Searcher.Count(r.Context(), d.Index, params)
+		switch {
+		case db.IsNotFound(err):
+			// Registered but not ingested yet
+		case err != nil:
+			sendFailure(w, "Failed to count emails of "+d.ID, err)
+			return
+		default:
+			d.Documents = total
+		}
+		counted[i] = d
+	}
+
+	response := Response{
+		Success: true,
+		Data:    counted,
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
+
+// Select looks up the dataset named by the {dataset} URL parameter and
+// makes it the dataset of the request, so the handlers behind it search
+// its index
+func (h *Dataset) Select(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "dataset")
+		if !dataset.IsValidID(id) {
+			sendError(w, "Invalid dataset id", http.StatusBadRequest)
+			return
+		}
+		selected, err := h.Registry.Get(r.Context(), id)
+		if errors.Is(err, dataset.ErrNotFound) {
+			sendError(w, "Unknown dataset: "+id, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			sendFailure(w, "Failed to look up dataset", err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(dataset.WithDataset(r.Context(), selected)))
+	})
+}
+
+// UseDataset makes d the dataset of the requests that do not select one,
+// so that the routes mounted at the root search its index
+func UseDataset(d models.Dataset) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(dataset.WithDataset(r.Context(), d)))
+		})
+	}
+}
+
+// scanLimit returns the configured cap of the emails read by a scan, or the
+// default one
+func scanLimit(max int) int {
+	if max > 0 {
+		return max
+	}
+	return email.MaxScanResults
+}
+
+// indexOf returns the index holding the emails of the dataset of a request
+func indexOf(r *http.Request) string {
+	return dataset.FromContext(r.Context()).Index
+}
+
+// metricsIndexOf returns the index holding the network metrics of the
+// dataset of a request, empty when it has not been analyzed
+func metricsIndexOf(r *http.Request) string {
+	return dataset.FromContext(r.Context()).PeopleMetricsIndex
+}
@@ -2,14 +2,36 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
 	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/spelling"
+	"github.com/DanielOsorio01/enron-email-search/back/synonyms"
+	"github.com/DanielOsorio01/enron-email-search/back/terms"
 )
 
 type Email struct {
-	Repo *email.ZincsearchRepo
+	// Searcher serves searches, counts and facets
+	Searcher email.EmailSearcher
+	// Repo serves the analyses that need ZincSearch aggregations, it is nil
+	// when another search backend is configured
+	Repo     *email.ZincsearchRepo
+	Terms    *terms.Stats
+	Spelling *spelling.Dictionary
+	Synonyms *synonyms.Store
+	// MaxResults caps the emails of a search page, no cap when zero
+	MaxResults int
+	// MaxScanResults caps the emails read by a concordance,
+	// email.MaxScanResults when zero
+	MaxScanResults int
 }
 
 // Response represents the standard API response structure
@@ -52,6 +74,9 @@ func (h *Email) List(w http.ResponseWriter, r *http.Request) {
 	if maxResults > 0 {
 		params.MaxResults = maxResults
 	}
+	if h.MaxResults > 0 && params.MaxResults > h.MaxResults {
+		params.MaxResults = h.MaxResults
+	}
 	if field != "" {
 		params.Field = field
 	}
@@ -59,6 +84,21 @@ func (h *Email) List(w http.ResponseWriter, r *http.Request) {
 		params.SearchType = searchType
 	}
 
+	// Restrict the search to a date range if provided
+	var err error
+	if params.StartTime, err = parseDate(query.Get("start")); err != nil {
+		sendError(w, "Invalid start date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.EndTime, err = parseEndDate(query.Get("end")); err != nil {
+		sendError(w, "Invalid end date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.SearchType == email.SearchTypeDateRange && params.StartTime.IsZero() && params.EndTime.IsZero() {
+		sendError(w, "A daterange search requires a start or an end date", http.StatusBadRequest)
+		return
+	}
+
 	// Get sort fields if provided
 	if sortFields := query["sort_fields"]; len(sortFields) > 0 {
 		params.SortFields = sortFields
@@ -69,35 +109,336 @@ func (h *Email) List(w http.ResponseWriter, r *http.Request) {
 		params.SourceFields = sourceFields
 	}
 
+	// Proximity and name searches need ZincSearch aggregations
+	if h.Repo == nil && (searchType == email.SearchTypeProximity || searchType == email.SearchTypePhonetic) {
+		sendError(w, "Search type "+searchType+" is not supported by the search backend", http.StatusNotImplemented)
+		return
+	}
+
+	// Proximity queries such as "prepay" w/10 "Citibank" are filtered in
+	// memory and report their own total, and whether it is truncated
+	if proximity, ok := email.ParseProximity(term); ok && h.Repo != nil && (searchType == "" || searchType == email.SearchTypeProximity) {
+		emails, total, truncated, err := h.Repo.SearchProximity(r.Context(), indexOf(r), proximity, params.StartTime, params.EndTime, params.From, params.MaxResults)
+		if err != nil {
+			sendFailure(w, "Failed to search emails", err)
+			return
+		}
+
+		response := Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"emails":    emails,
+				"total":     total,
+				"from":      params.From,
+				"size":      len(emails),
+				"truncated": truncated,
+			},
+		}
+
+		sendJSON(w, response, http.StatusOK)
+		return
+	} else if searchType == email.SearchTypeProximity {
+		sendError(w, `Proximity queries must look like "term" w/N "term" or "term" pre/N "term"`, http.StatusBadRequest)
+		return
+	}
+
+	// Name searches are ranked in memory and report their own total, and
+	// whether it is truncated
+	if params.SearchType == email.SearchTypePhonetic {
+		matches, total, truncated, err := h.Repo.SearchNames(r.Context(), indexOf(r), term, params.StartTime, params.EndTime, params.From, params.MaxResults)
+		if err != nil {
+			sendFailure(w, "Failed to search names", err)
+			return
+		}
+
+		response := Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"emails":    matches,
+				"total":     total,
+				"from":      params.From,
+				"size":      len(matches),
+				"truncated": truncated,
+			},
+		}
+
+		sendJSON(w, response, http.StatusOK)
+		return
+	}
+
+	// Expand synonyms of match and query string searches unless disabled
+	var expansions []synonyms.Expansion
+	expandable := params.SearchType == email.SearchTypeMatch || params.SearchType == email.SearchTypeQueryString
+	if h.Synonyms != nil && expandable && query.Get("expand") != "false" {
+		var expanded string
+		if params.SearchType == email.SearchTypeMatch {
+			expanded, expansions = h.Synonyms.Set().ExpandText(term)
+		} else {
+			expanded, expansions = h.Synonyms.Set().Expand(term)
+		}
+		if len(expansions) > 0 {
+			params.Term = expanded
+			params.SearchType = email.SearchTypeQueryString
+		}
+	}
+
 	// Perform the search
-	emails, err := h.Repo.Search(r.Context(), "enron_emails", params)
+	emails, err := h.Searcher.Search(r.Context(), indexOf(r), params)
 	if err != nil {
-		sendError(w, "Failed to search emails: "+err.Error(), http.StatusInternalServerError)
+		sendFailure(w, "Failed to search emails", err)
 		return
 	}
 
 	// Get total count for the search term
-	total, err := h.Repo.CountResults(r.Context(), "enron_emails", term)
+	total, err := h.Searcher.Count(r.Context(), indexOf(r), params)
 	if err != nil {
-		sendError(w, "Failed to get total count: "+err.Error(), http.StatusInternalServerError)
+		sendFailure(w, "Failed to get total count", err)
 		return
 	}
 
+	data := map[string]interface{}{
+		"emails": emails,
+		"total":  total,
+		"from":   params.From,
+		"size":   len(emails),
+	}
+	if len(expansions) > 0 {
+		data["expansions"] = expansions
+	}
+
+	// Suggest corrections when the term finds (almost) nothing
+	if h.Spelling != nil && total < email.SuggestionThreshold {
+		if suggestions := h.Spelling.Suggest(term, email.MaxSuggestions); len(suggestions) > 0 {
+			data["suggestions"] = suggestions
+		}
+	}
+
 	// Prepare response
 	response := Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"emails": emails,
-			"total":  total,
-			"from":   params.From,
-			"size":   len(emails),
-		},
+		Data:    data,
 	}
 
 	// Send response
 	sendJSON(w, response, http.StatusOK)
 }
 
+// Timeline returns the number of emails matching the query per day, week or
+// month, optionally split by sender or custodian
+func (h *Email) Timeline(w http.ResponseWriter, r *http.Request) {
+	if !h.requireRepo(w) {
+		return
+	}
+
+	params, err := parseTimelineParams(r.URL.Query())
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeline, err := h.Repo.Timeline(r.Context(), indexOf(r), params)
+	if err != nil {
+		sendFailure(w, "Failed to build timeline", err)
+		return
+	}
+
+	sendJSON(w, Response{Success: true, Data: timeline}, http.StatusOK)
+}
+
+// Trends compares how often several terms, given as repeated terms
+// parameters, appear over time
+func (h *Email) Trends(w http.ResponseWriter, r *http.Request) {
+	if !h.requireRepo(w) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	terms := query["terms"]
+	if len(terms) == 0 {
+		sendError(w, "At least one term is required", http.StatusBadRequest)
+		return
+	}
+	if len(terms) > email.MaxTrendTerms {
+		sendError(w, fmt.Sprintf("At most %d terms can be compared", email.MaxTrendTerms), http.StatusBadRequest)
+		return
+	}
+
+	params, err := parseTimelineParams(query)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trends, err := h.Repo.Trends(r.Context(), indexOf(r), terms, params)
+	if err != nil {
+		sendFailure(w, "Failed to build trends", err)
+		return
+	}
+
+	sendJSON(w, Response{Success: true, Data: trends}, http.StatusOK)
+}
+
+// Get returns the email identified by the {id} URL parameter, its message id
+func (h *Email) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil || id == "" {
+		sendError(w, "Invalid message id", http.StatusBadRequest)
+		return
+	}
+
+	found, err := h.Searcher.Get(r.Context(), indexOf(r), id)
+	if errors.Is(err, email.ErrNotFound) {
+		sendError(w, "Email not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendFailure(w, "Failed to get email", err)
+		return
+	}
+
+	sendJSON(w, Response{Success: true, Data: found}, http.StatusOK)
+}
+
+// Facets counts the senders, recipients, folders or custodians of the
+// emails matching a search. The counted fields are given as repeated
+// fields parameters and default to all of them.
+func (h *Email) Facets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := email.DefaultSearchParams()
+	params.SearchType = email.SearchTypeMatchAll
+	if term := query.Get("term"); term != "" {
+		params.Term = term
+		params.SearchType = email.SearchTypeMatch
+	}
+	if searchType := query.Get("search_type"); searchType != "" {
+		params.SearchType = searchType
+	}
+	params.Field = query.Get("field")
+
+	var err error
+	if params.StartTime, err = parseDate(query.Get("start")); err != nil {
+		sendError(w, "Invalid start date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.EndTime, err = parseEndDate(query.Get("end")); err != nil {
+		sendError(w, "Invalid end date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := query["fields"]
+	if len(fields) == 0 {
+		fields = email.FacetFields
+	}
+	for _, field := range fields {
+		if !email.IsFacetField(field) {
+			sendError(w, "Unknown facet field: "+field, http.StatusBadRequest)
+			return
+		}
+	}
+
+	size := email.DefaultTopN
+	if value := query.Get("size"); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil || size < 1 || size > email.MaxFacetSize {
+			sendError(w, fmt.Sprintf("Size must be between 1 and %d", email.MaxFacetSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	facets, err := h.Searcher.Facets(r.Context(), indexOf(r), params, fields, size)
+	if err != nil {
+		sendFailure(w, "Failed to count facets", err)
+		return
+	}
+
+	sendJSON(w, Response{Success: true, Data: facets}, http.StatusOK)
+}
+
+// requireRepo reports whether the ZincSearch repository is available,
+// answering 501 when the configured search backend cannot serve the request
+func (h *Email) requireRepo(w http.ResponseWriter) bool {
+	if h.Repo == nil {
+		sendError(w, "Not supported by the search backend", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// parseTimelineParams reads the term, interval, split, time zone and date
+// range parameters shared by the timeline endpoints
+func parseTimelineParams(query url.Values) (email.TimelineParams, error) {
+	params := email.DefaultTimelineParams()
+	params.Term = query.Get("term")
+
+	if interval := query.Get("interval"); interval != "" {
+		if !email.IsInterval(interval) {
+			return params, errors.New("Interval must be one of day, week or month")
+		}
+		params.Interval = interval
+	}
+
+	if split := query.Get("split"); split != "" {
+		if !email.IsSplit(split) {
+			return params, errors.New("Split must be one of sender or custodian")
+		}
+		params.Split = split
+	}
+
+	if tz := query.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return params, fmt.Errorf("Invalid time zone: %w", err)
+		}
+		params.Location = loc
+	}
+
+	var err error
+	if params.StartTime, err = parseDateIn(query.Get("start"), params.Location); err != nil {
+		return params, fmt.Errorf("Invalid start date: %w", err)
+	}
+	if params.EndTime, err = parseEndDateIn(query.Get("end"), params.Location); err != nil {
+		return params, fmt.Errorf("Invalid end date: %w", err)
+	}
+
+	return params, nil
+}
+
+// parseDate parses an optional date given either as RFC 3339 or as YYYY-MM-DD
+func parseDate(value string) (time.Time, error) {
+	return parseDateIn(value, time.UTC)
+}
+
+// parseDateIn parses an optional date given either as RFC 3339 or as
+// YYYY-MM-DD, in which case it is interpreted in loc
+func parseDateIn(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// parseEndDate parses an optional end date like parseDate. Ranges include
+// their end, so a date without a time stands for the last second of that
+// day.
+func parseEndDate(value string) (time.Time, error) {
+	return parseEndDateIn(value, time.UTC)
+}
+
+// parseEndDateIn parses an optional end date like parseDateIn, ending a
+// date without a time at the last second of that day in loc
+func parseEndDateIn(value string, loc *time.Location) (time.Time, error) {
+	t, err := parseDateIn(value, loc)
+	if err != nil || len(value) != len("2006-01-02") {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
 // Helper function to send JSON response
 func sendJSON(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -105,6 +446,17 @@ func sendJSON(w http.ResponseWriter, data interface{}, status int) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// sendFailure reports a failed search backend request. It answers 503
+// while Zinc is unavailable, so that clients and load balancers back off
+// instead of retrying at once.
+func sendFailure(w http.ResponseWriter, message string, err error) {
+	status := http.StatusInternalServerError
+	if db.IsUnavailable(err) {
+		status = http.StatusServiceUnavailable
+	}
+	sendError(w, message+": "+err.Error(), status)
+}
+
 // Helper function to send error response
 func sendError(w http.ResponseWriter, message string, status int) {
 	response := Response{
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEndDate(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"", time.UTC, time.Time{}},
+		{"2001-09-30", time.UTC, time.Date(2001, 9, 30, 23, 59, 59, 0, time.UTC)},
+		{"2001-09-30T12:00:00Z", time.UTC, time.Date(2001, 9, 30, 12, 0, 0, 0, time.UTC)},
+		{"2001-10-28", chicago, time.Date(2001, 10, 28, 23, 59, 59, 0, chicago)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseEndDateIn(test.value, test.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+
+	if _, err := parseEndDate("30/09/2001"); err == nil {
+		t.Error("expected an invalid date to be rejected")
+	}
+}
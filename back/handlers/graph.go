@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/DanielOsorio01/enron-email-search/back/graph"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+type Graph struct {
+	Repo *email.ZincsearchRepo
+	// MaxScanResults caps the emails read to build a graph,
+	// email.MaxScanResults when zero
+	MaxScanResults int
+}
+
+// Get builds the sender to recipient graph of the emails matching the query.
+// The graph is returned as JSON, or as GraphML or GEXF when requested with
+// the format parameter.
+func (h *Graph) Get(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	start, err := parseDate(query.Get("start"))
+	if err != nil {
+		sendError(w, "Invalid start date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := parseEndDate(query.Get("end"))
+	if err != nil {
+		sendError(w, "Invalid end date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "graphml" && format != "gexf" {
+		sendError(w, "Format must be one of json, graphml or gexf", http.StatusBadRequest)
+		return
+	}
+
+	builder := graph.NewBuilder()
+	filter := email.FilterQuery(query.Get("term"), start, end)
+	limit := scanLimit(h.MaxScanResults)
+	total, err := h.Repo.Scan(r.Context(), indexOf(r), filter, []string{"from", "to", "cc", "bcc"}, limit,
+		func(hit email.SearchHitItem) error {
+			builder.Add(hit.Source.From, hit.Source.To, hit.Source.Cc, hit.Source.Bcc)
+			return nil
+		})
+	if err != nil {
+		sendFailure(w, "Failed to build graph", err)
+		return
+	}
+
+	g := builder.Graph()
+	g.Truncated = total > limit
+
+	switch format {
+	case "graphml":
+		w.Header().Set("Content-Type", "application/graphml+xml")
+		w.Header().Set("Content-Disposition", `attachment; filename="emails.graphml"`)
+		err = graph.WriteGraphML(w, g)
+	case "gexf":
+		w.Header().Set("Content-Type", "application/gexf+xml")
+		w.Header().Set("Content-Disposition", `attachment; filename="emails.gexf"`)
+		err = graph.WriteGEXF(w, g)
+	default:
+		sendJSON(w, Response{Success: true, Data: g}, http.StatusOK)
+	}
+	// The status is already sent, a failed export can only be logged
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to write graph", "format", format, "error", err)
+	}
+}
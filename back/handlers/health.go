@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+// readinessTimeout bounds the checks of a readiness probe, so that a
+// probe fails rather than hangs while Zinc is slow
+const readinessTimeout = 5 * time.Second
+
+// Health serves the liveness and readiness probes
+type Health struct {
+	Backend string
+	Index   string
+	// Timeout bounds the checks, readinessTimeout when zero
+	Timeout  time.Duration
+	Searcher email.EmailSearcher
+	// Repo is nil when the emails are not searched in ZincSearch
+	Repo *email.ZincsearchRepo
+}
+
+// Live reports that the server is running. It does not depend on Zinc, so
+// that an orchestrator does not restart the backend while Zinc is down.
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, Response{
+		Success: true,
+		Data:    map[string]string{"status": "ok"},
+	}, http.StatusOK)
+}
+
+// Ready reports whether searches can be served: Zinc answers requests with
+// the configured credentials, and the index exists with the mapping the
+// queries need. It answers 503 with the failed checks otherwise.
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = readinessTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	var report models.Readiness
+	if h.Repo != nil {
+		report = h.zincReadiness(ctx)
+	} else {
+		report = h.searcherReadiness(ctx)
+	}
+
+	report.Ready = true
+	for _, check := range report.Checks {
+		report.Ready = report.Ready && check.OK
+	}
+
+	if !report.Ready {
+		sendJSON(w, Response{
+			Success: false,
+			Error:   "Not ready",
+			Data:    report,
+		}, http.StatusServiceUnavailable)
+		return
+	}
+	sendJSON(w, Response{
+		Success: true,
+		Data:    report,
+	}, http.StatusOK)
+}
+
+// zincReadiness checks Zinc, then the index and its mapping once Zinc is
+// known to answer
+func (h *Health) zincReadiness(ctx context.Context) models.Readiness {
+	report := models.Readiness{Backend: h.Backend}
+
+	err := h.Repo.Client.Ping(ctx)
+	report.Checks = append(report.Checks, check("zinc", err))
+	if err != nil {
+		return report
+	}
+
+	status, err := h.Repo.Status(ctx, h.Index)
+	report.Checks = append(report.Checks, check("index", err))
+	if err != nil {
+		return report
+	}
+	report.Index = status
+
+	report.Checks = append(report.Checks, check("mapping", h.Repo.CheckMapping(ctx, h.Index)))
+	return report
+}
+
+// searcherReadiness counts the emails of the in-process backends, which
+// are ready as soon as the server runs
+func (h *Health) searcherReadiness(ctx context.Context) models.Readiness {
+	report := models.Readiness{Backend: h.Backend}
+
+	params := email.DefaultSearchParams()
+	params.SearchType = email.SearchTypeMatchAll
+	total, err := h.Searcher.Count(ctx, h.Index, params)
+	report.Checks = append(report.Checks, check("index", err))
+	if err == nil {
+		report.Index = &models.IndexStatus{Name: h.Index, Index: h.Index, Documents: total}
+	}
+	return report
+}
+
+func check(name string, err error) models.Check {
+	if err != nil {
+		return models.Check{Name: name, Error: err.Error()}
+	}
+	return models.Check{Name: name, OK: true}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/network"
+)
+
+type Person struct {
+	Repo     *email.ZincsearchRepo
+	Network  *network.ZincsearchRepo
+	Profiles *cache.Cache[string, *models.PersonProfile]
+}
+
+// Profile returns the activity summary of the person identified by the {id} URL parameter
+func (h *Person) Profile(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		sendError(w, "Invalid person id", http.StatusBadRequest)
+		return
+	}
+	id = email.NormalizeAddress(id)
+	if id == "" {
+		sendError(w, "Person id is required", http.StatusBadRequest)
+		return
+	}
+
+	// Profiles are expensive to compute, serve them from the cache when possible
+	index := indexOf(r)
+	key := index + "/" + id
+	profile, ok := h.Profiles.Get(key)
+	if !ok {
+		profile, err = h.Repo.PersonProfile(r.Context(), index, id)
+		if err != nil {
+			sendFailure(w, "Failed to build profile", err)
+			return
+		}
+		h.Profiles.Set(key, profile)
+	}
+
+	response := Response{
+		Success: true,
+		Data:    profile,
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
+
+// Central returns the most central people of a period, ranked by the metric
+// given in the by parameter
+func (h *Person) Central(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	period := query.Get("period")
+	if period == "" {
+		period = network.PeriodAll
+	}
+	metric := query.Get("by")
+	if metric == "" {
+		metric = network.MetricPageRank
+	}
+	if !network.IsMetric(metric) {
+		sendError(w, "Metric must be one of pagerank, betweenness or degree_centrality", http.StatusBadRequest)
+		return
+	}
+
+	index := metricsIndexOf(r)
+	if index == "" {
+		sendError(w, "No network metrics for this dataset", http.StatusNotFound)
+		return
+	}
+
+	people, err := h.Network.Central(r.Context(), index, period, metric, parseLimit(query.Get("limit")))
+	if err != nil {
+		sendFailure(w, "Failed to get central people", err)
+		return
+	}
+
+	response := Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"period": period,
+			"by":     metric,
+			"people": people,
+		},
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
+
+// Community returns the community the person identified by the {id} URL
+// parameter belongs to in a period, with its most central members
+func (h *Person) Community(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		sendError(w, "Invalid person id", http.StatusBadRequest)
+		return
+	}
+	id = email.NormalizeAddress(id)
+
+	query := r.URL.Query()
+	period := query.Get("period")
+	if period == "" {
+		period = network.PeriodAll
+	}
+
+	index := metricsIndexOf(r)
+	if index == "" {
+		sendError(w, "No network metrics for this dataset", http.StatusNotFound)
+		return
+	}
+
+	community, err := h.Network.Community(r.Context(), index, id, period, parseLimit(query.Get("limit")))
+	if err != nil {
+		sendFailure(w, "Failed to get community", err)
+		return
+	}
+	if community == nil {
+		sendError(w, "No network metrics for this person and period", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, Response{Success: true, Data: community}, http.StatusOK)
+}
+
+// parseLimit parses the limit parameter, falling back to the default when
+// it is missing or out of range
+func parseLimit(value string) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return network.DefaultLimit
+	}
+	if limit > network.MaxLimit {
+		return network.MaxLimit
+	}
+	return limit
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/terms"
+)
+
+// Related suggests the terms that co-occur most strongly with the search
+// term, comparing the top hits of the search against the whole corpus
+func (h *Email) Related(w http.ResponseWriter, r *http.Request) {
+	if h.Terms == nil {
+		sendError(w, "Term statistics are not loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	term := query.Get("term")
+	if term == "" {
+		sendError(w, "Search term is required", http.StatusBadRequest)
+		return
+	}
+
+	method := query.Get("method")
+	if method == "" {
+		method = terms.MethodLLR
+	}
+	if method != terms.MethodLLR && method != terms.MethodPMI {
+		sendError(w, "Method must be one of llr or pmi", http.StatusBadRequest)
+		return
+	}
+
+	size := email.DefaultRelatedHits
+	if n, err := strconv.Atoi(query.Get("size")); err == nil && n > 0 {
+		size = min(n, email.MaxRelatedHits)
+	}
+	limit := email.DefaultTopN
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		limit = min(n, email.MaxRelatedTerms)
+	}
+
+	// Fetch the most relevant hits of the query
+	params := email.DefaultSearchParams()
+	params.Term = term
+	params.MaxResults = size
+	params.SortFields = []string{}
+	params.SourceFields = []string{"subject", "body"}
+	if field := query.Get("field"); field != "" {
+		params.Field = field
+	}
+	if searchType := query.Get("search_type"); searchType != "" {
+		params.SearchType = searchType
+	}
+
+	emails, err := h.Searcher.Search(r.Context(), indexOf(r), params)
+	if err != nil {
+		sendFailure(w, "Failed to search emails", err)
+		return
+	}
+
+	documents := make([]string, len(emails))
+	for i, e := range emails {
+		documents[i] = e.Subject + "\n" + e.Body
+	}
+
+	response := Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"term":    term,
+			"method":  method,
+			"hits":    len(documents),
+			"related": h.Terms.Related(documents, term, method, limit),
+		},
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
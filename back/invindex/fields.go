@@ -0,0 +1,124 @@
+package invindex
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// AllField searches every field of an email, like the _all field of Zinc
+const AllField = "_all"
+
+// field describes how a field of an email is indexed. Text fields are
+// split into lowercase words, keyword fields are indexed as exact values.
+// Stemmed text fields also drop stop words and stem the others, like the
+// email_english analyzer the Zinc mapping applies to the subject and body.
+type field struct {
+	text    bool
+	stemmed bool
+	values  func(e *models.Email) []string
+}
+
+func one(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// fields maps the JSON name of every searchable field to its definition,
+// matching the mapping created by `load-data index create`
+var fields = map[string]field{
+	"message_id":                {values: func(e *models.Email) []string { return one(e.MessageID) }},
+	"from":                      {values: func(e *models.Email) []string { return one(e.From) }},
+	"to":                        {values: func(e *models.Email) []string { return e.To }},
+	"cc":                        {values: func(e *models.Email) []string { return e.Cc }},
+	"bcc":                       {values: func(e *models.Email) []string { return e.Bcc }},
+	"subject":                   {text: true, stemmed: true, values: func(e *models.Email) []string { return one(e.Subject) }},
+	"mime_version":              {values: func(e *models.Email) []string { return one(e.MimeVersion) }},
+	"content_type":              {values: func(e *models.Email) []string { return one(e.ContentType) }},
+	"content_transfer_encoding": {values: func(e *models.Email) []string { return one(e.ContentTransferEncoding) }},
+	"x_from":                    {text: true, values: func(e *models.Email) []string { return one(e.XFrom) }},
+	"x_to":                      {text: true, values: func(e *models.Email) []string { return e.XTo }},
+	"x_cc":                      {text: true, values: func(e *models.Email) []string { return e.XCc }},
+	"x_bcc":                     {text: true, values: func(e *models.Email) []string { return e.XBcc }},
+	"x_folder":                  {values: func(e *models.Email) []string { return one(e.XFolder) }},
+	"x_origin":                  {values: func(e *models.Email) []string { return one(e.XOrigin) }},
+	"x_filename":                {values: func(e *models.Email) []string { return one(e.XFileName) }},
+	"body":                      {text: true, stemmed: true, values: func(e *models.Email) []string { return one(e.Body) }},
+	"from_phonetic":             {values: func(e *models.Email) []string { return e.FromPhonetic }},
+	"recipients_phonetic":       {values: func(e *models.Email) []string { return e.RecipientsPhonetic }},
+}
+
+// IsField reports whether name is a searchable field
+func IsField(name string) bool {
+	_, ok := fields[name]
+	return ok || name == AllField
+}
+
+// IsKeyword reports whether name is a keyword field, the fields that can
+// be used as facets
+func IsKeyword(name string) bool {
+	f, ok := fields[name]
+	return ok && !f.text
+}
+
+// isText reports whether the values of a field are split into words. The
+// _all field holds the words of every field.
+func isText(name string) bool {
+	f, ok := fields[name]
+	return !ok || f.text
+}
+
+// positionGap separates the values of a multi-valued field so that a
+// phrase never matches across two of them
+const positionGap = 100
+
+// Analyze splits text into the lowercase words stored for text fields
+func Analyze(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// analyzed is a term of a value and the position of its word in the value
+type analyzed struct {
+	term     string
+	position int
+}
+
+// analyzeWords returns the terms stored for the words of a value of a text
+// field. Stop words removed from a stemmed field keep their position, so
+// that phrases do not match across them.
+func analyzeWords(name string, words []string) []analyzed {
+	stemmed := fields[name].stemmed
+	terms := make([]analyzed, 0, len(words))
+	for i, word := range words {
+		switch {
+		case !stemmed:
+			terms = append(terms, analyzed{word, i})
+		case !concordance.IsStopword(word):
+			terms = append(terms, analyzed{Stem(word), i})
+		}
+	}
+	return terms
+}
+
+// positioned returns the terms a query value is looked up with in a field
+func positioned(name, value string) []analyzed {
+	if isText(name) {
+		return analyzeWords(name, Analyze(value))
+	}
+	return []analyzed{{value, 0}}
+}
+
+// terms returns the terms a query value is looked up with in a field
+func terms(name, value string) []string {
+	var result []string
+	for _, t := range positioned(name, value) {
+		result = append(result, t.term)
+	}
+	return result
+}
@@ -0,0 +1,212 @@
+// Package invindex is a small inverted index of emails held in memory. It
+// supports the search types of the Zinc search API, which makes it usable
+// as a search backend for small corpora and tests.
+package invindex
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Posting lists the positions of a term in one document
+type Posting struct {
+	Doc       int
+	Positions []int
+}
+
+// Index is an inverted index of emails. It is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	docs []models.Email
+	// postings maps a field and a term to its postings, ordered by document
+	postings map[string]map[string][]Posting
+}
+
+// New creates an empty index
+func New() *Index {
+	return &Index{postings: map[string]map[string][]Posting{}}
+}
+
+// Len returns the number of emails in the index
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Add indexes emails
+func (ix *Index) Add(emails ...models.Email) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for i := range emails {
+		doc := len(ix.docs)
+		ix.docs = append(ix.docs, emails[i])
+
+		allPosition := 0
+		for name, f := range fields {
+			position := 0
+			for _, value := range f.values(&emails[i]) {
+				words := Analyze(value)
+				if f.text {
+					for _, t := range analyzeWords(name, words) {
+						ix.addPosting(name, t.term, doc, position+t.position)
+					}
+					position += len(words) + positionGap
+				} else {
+					ix.addPosting(name, value, doc, position)
+					position += positionGap
+				}
+				// Keyword values are split into words for _all, so that an
+				// address can be found by any of its parts
+				for _, word := range words {
+					ix.addPosting(AllField, word, doc, allPosition)
+					allPosition++
+				}
+				allPosition += positionGap
+			}
+		}
+	}
+}
+
+func (ix *Index) addPosting(name, term string, doc, position int) {
+	terms, ok := ix.postings[name]
+	if !ok {
+		terms = map[string][]Posting{}
+		ix.postings[name] = terms
+	}
+	postings := terms[term]
+	if n := len(postings); n > 0 && postings[n-1].Doc == doc {
+		postings[n-1].Positions = append(postings[n-1].Positions, position)
+		return
+	}
+	terms[term] = append(postings, Posting{Doc: doc, Positions: []int{position}})
+}
+
+// Request selects the page of results of a search
+type Request struct {
+	From int
+	Size int
+	// Sort lists the fields results are ordered by, prefixed with - for a
+	// descending order. Without sort fields results keep the order in
+	// which they were added.
+	Sort []string
+}
+
+// Result is a page of the emails matching a query
+type Result struct {
+	Total  int
+	Emails []models.Email
+}
+
+// Search returns the emails matching q
+func (ix *Index) Search(q Query, req Request) Result {
+	total, docs := ix.Find(q, req)
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	result := Result{Total: total}
+	for _, doc := range docs {
+		result.Emails = append(result.Emails, ix.docs[doc])
+	}
+	return result
+}
+
+// Find returns the number of emails matching q and the requested page of
+// their document numbers, which count the emails in the order they were
+// added starting from 0
+func (ix *Index) Find(q Query, req Request) (int, []int) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	matches := q.eval(ix).sorted()
+	ix.sortDocs(matches, req.Sort)
+
+	if req.From >= len(matches) || req.Size <= 0 {
+		return len(matches), nil
+	}
+	end := min(req.From+req.Size, len(matches))
+	return len(matches), matches[req.From:end]
+}
+
+// Count returns the number of emails matching q
+func (ix *Index) Count(q Query) int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(q.eval(ix))
+}
+
+// Facets counts the values of keyword fields among the emails matching q,
+// returning the size most frequent values of each field
+func (ix *Index) Facets(q Query, names []string, size int) map[string][]models.FacetCount {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	matches := q.eval(ix)
+	facets := make(map[string][]models.FacetCount, len(names))
+	for _, name := range names {
+		f, ok := fields[name]
+		if !ok || f.text {
+			continue
+		}
+
+		counts := map[string]int{}
+		for doc := range matches {
+			for _, value := range f.values(&ix.docs[doc]) {
+				counts[value]++
+			}
+		}
+
+		values := make([]models.FacetCount, 0, len(counts))
+		for value, count := range counts {
+			values = append(values, models.FacetCount{Value: value, Count: count})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return values[i].Value < values[j].Value
+		})
+		if size > 0 && len(values) > size {
+			values = values[:size]
+		}
+		facets[name] = values
+	}
+	return facets
+}
+
+// sortDocs orders documents by the given sort fields. @timestamp is the
+// ingest time in Zinc, here it sorts by the order emails were added.
+func (ix *Index) sortDocs(docs []int, sortFields []string) {
+	if len(sortFields) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := &ix.docs[docs[i]], &ix.docs[docs[j]]
+		for _, sortField := range sortFields {
+			name, descending := strings.CutPrefix(sortField, "-")
+			name = strings.TrimPrefix(name, "+")
+
+			var c int
+			if name == "@timestamp" {
+				c = docs[i] - docs[j]
+			} else if name == "date" {
+				c = a.Date.Compare(b.Date)
+			} else if f, ok := fields[name]; ok {
+				c = strings.Compare(strings.Join(f.values(a), ","), strings.Join(f.values(b), ","))
+			}
+			if c == 0 {
+				continue
+			}
+			if descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,51 @@
+package invindex
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// FormatVersion is the version of the encoded index. Indexes written with
+// another version must be rebuilt.
+const FormatVersion = 2
+
+// snapshot is the encoded form of an index
+type snapshot struct {
+	Version  int
+	Docs     []models.Email
+	Postings map[string]map[string][]Posting
+}
+
+// Encode writes the index to w
+func (ix *Index) Encode(w io.Writer) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	err := gob.NewEncoder(w).Encode(snapshot{
+		Version:  FormatVersion,
+		Docs:     ix.docs,
+		Postings: ix.postings,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	return nil
+}
+
+// Decode reads an index written by Encode
+func Decode(r io.Reader) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if s.Version != FormatVersion {
+		return nil, fmt.Errorf("index format version %d is not supported, expected %d", s.Version, FormatVersion)
+	}
+	if s.Postings == nil {
+		s.Postings = map[string]map[string][]Posting{}
+	}
+	return &Index{docs: s.Docs, postings: s.Postings}, nil
+}
@@ -0,0 +1,290 @@
+package invindex
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/spelling"
+)
+
+// docSet is a set of document numbers
+type docSet map[int]struct{}
+
+func (s docSet) sorted() []int {
+	docs := make([]int, 0, len(s))
+	for doc := range s {
+		docs = append(docs, doc)
+	}
+	sort.Ints(docs)
+	return docs
+}
+
+func intersect(a, b docSet) docSet {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	result := docSet{}
+	for doc := range a {
+		if _, ok := b[doc]; ok {
+			result[doc] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Query selects emails of an index
+type Query interface {
+	eval(ix *Index) docSet
+}
+
+// MatchAll matches every email
+type MatchAll struct{}
+
+func (MatchAll) eval(ix *Index) docSet {
+	docs := make(docSet, len(ix.docs))
+	for doc := range ix.docs {
+		docs[doc] = struct{}{}
+	}
+	return docs
+}
+
+// Term matches the emails holding the exact term in a field
+type Term struct {
+	Field string
+	Value string
+}
+
+func (q Term) eval(ix *Index) docSet {
+	value := q.Value
+	if isText(q.Field) {
+		value = strings.ToLower(value)
+	}
+	docs := docSet{}
+	for _, p := range ix.postings[q.Field][value] {
+		docs[p.Doc] = struct{}{}
+	}
+	return docs
+}
+
+// Match matches the emails holding any word of a text in a field
+type Match struct {
+	Field string
+	Text  string
+}
+
+func (q Match) eval(ix *Index) docSet {
+	docs := docSet{}
+	for _, term := range terms(q.Field, q.Text) {
+		for _, p := range ix.postings[q.Field][term] {
+			docs[p.Doc] = struct{}{}
+		}
+	}
+	return docs
+}
+
+// Phrase matches the emails holding the words of a text next to each
+// other and in order
+type Phrase struct {
+	Field string
+	Text  string
+}
+
+func (q Phrase) eval(ix *Index) docSet {
+	words := positioned(q.Field, q.Text)
+	if len(words) == 0 {
+		return docSet{}
+	}
+
+	// Positions where the phrase could start, narrowed word by word. Words
+	// keep their offset from the first one, the gaps left by stop words
+	// match any word.
+	starts := map[int]map[int]bool{}
+	for _, p := range ix.postings[q.Field][words[0].term] {
+		starts[p.Doc] = map[int]bool{}
+		for _, position := range p.Positions {
+			starts[p.Doc][position] = true
+		}
+	}
+	for _, word := range words[1:] {
+		offset := word.position - words[0].position
+		next := map[int]map[int]bool{}
+		for _, p := range ix.postings[q.Field][word.term] {
+			candidates, ok := starts[p.Doc]
+			if !ok {
+				continue
+			}
+			for _, position := range p.Positions {
+				start := position - offset
+				if candidates[start] {
+					if next[p.Doc] == nil {
+						next[p.Doc] = map[int]bool{}
+					}
+					next[p.Doc][start] = true
+				}
+			}
+		}
+		starts = next
+	}
+
+	docs := docSet{}
+	for doc := range starts {
+		docs[doc] = struct{}{}
+	}
+	return docs
+}
+
+// matchTerms matches the emails holding any term of a field accepted by fn
+func (ix *Index) matchTerms(name string, fn func(term string) bool) docSet {
+	docs := docSet{}
+	for term, postings := range ix.postings[name] {
+		if !fn(term) {
+			continue
+		}
+		for _, p := range postings {
+			docs[p.Doc] = struct{}{}
+		}
+	}
+	return docs
+}
+
+// Prefix matches the emails holding a term that starts with a prefix
+type Prefix struct {
+	Field  string
+	Prefix string
+}
+
+func (q Prefix) eval(ix *Index) docSet {
+	prefix := q.Prefix
+	if isText(q.Field) {
+		prefix = strings.ToLower(prefix)
+	}
+	return ix.matchTerms(q.Field, func(term string) bool {
+		return strings.HasPrefix(term, prefix)
+	})
+}
+
+// Wildcard matches the emails holding a term that matches a pattern where
+// * stands for any run of characters and ? for a single character
+type Wildcard struct {
+	Field   string
+	Pattern string
+}
+
+func (q Wildcard) eval(ix *Index) docSet {
+	pattern := q.Pattern
+	if isText(q.Field) {
+		pattern = strings.ToLower(pattern)
+	}
+	re := wildcardRegexp(pattern)
+	return ix.matchTerms(q.Field, re.MatchString)
+}
+
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// AutoFuzziness picks the edit distance of a fuzzy query from the length
+// of its term, like the AUTO fuzziness of Elasticsearch
+const AutoFuzziness = -1
+
+// Fuzzy matches the emails holding a term within an edit distance of the
+// given one
+type Fuzzy struct {
+	Field     string
+	Term      string
+	Fuzziness int
+}
+
+func (q Fuzzy) eval(ix *Index) docSet {
+	term := q.Term
+	if isText(q.Field) {
+		term = strings.ToLower(term)
+	}
+	fuzziness := q.Fuzziness
+	if fuzziness == AutoFuzziness {
+		switch n := len([]rune(term)); {
+		case n <= 2:
+			fuzziness = 0
+		case n <= 5:
+			fuzziness = 1
+		default:
+			fuzziness = 2
+		}
+	}
+	return ix.matchTerms(q.Field, func(candidate string) bool {
+		return spelling.Distance(term, candidate) <= fuzziness
+	})
+}
+
+// DateRange matches the emails sent between Start and End, both
+// inclusive. A zero bound leaves that side of the range open.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (q DateRange) eval(ix *Index) docSet {
+	docs := docSet{}
+	for doc := range ix.docs {
+		date := ix.docs[doc].Date
+		if !q.Start.IsZero() && date.Before(q.Start) {
+			continue
+		}
+		if !q.End.IsZero() && date.After(q.End) {
+			continue
+		}
+		docs[doc] = struct{}{}
+	}
+	return docs
+}
+
+// Bool combines queries. An email matches when it matches every Must
+// query and none of the MustNot queries. Should queries are required to
+// match one of them only when there is no Must query.
+type Bool struct {
+	Must    []Query
+	Should  []Query
+	MustNot []Query
+}
+
+func (q Bool) eval(ix *Index) docSet {
+	var docs docSet
+	switch {
+	case len(q.Must) > 0:
+		docs = q.Must[0].eval(ix)
+		for _, must := range q.Must[1:] {
+			docs = intersect(docs, must.eval(ix))
+		}
+	case len(q.Should) > 0:
+		docs = docSet{}
+		for _, should := range q.Should {
+			for doc := range should.eval(ix) {
+				docs[doc] = struct{}{}
+			}
+		}
+	default:
+		docs = MatchAll{}.eval(ix)
+	}
+
+	for _, mustNot := range q.MustNot {
+		for doc := range mustNot.eval(ix) {
+			delete(docs, doc)
+		}
+	}
+	return docs
+}
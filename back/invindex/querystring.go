@@ -0,0 +1,236 @@
+package invindex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseQueryString parses the subset of the Lucene query string syntax
+// that Zinc users rely on: words and "quoted phrases", field:value
+// clauses, + and - prefixes, the AND, OR and NOT operators, parentheses,
+// * and ? wildcards and term~ fuzzy terms. Words without a field search
+// defaultField.
+func ParseQueryString(query, defaultField string) Query {
+	p := &parser{tokens: lex(query)}
+	return p.parseBool(false, defaultField)
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPhrase
+	tokenField
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// lex splits a query string into words, phrases, field prefixes and
+// parentheses
+func lex(query string) []token {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, value: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !strings.ContainsRune(" \t\n()\"", runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			// A field name directly followed by a phrase or a group
+			if strings.HasSuffix(word, ":") && end < len(runes) && (runes[end] == '"' || runes[end] == '(') {
+				tokens = append(tokens, token{kind: tokenField, value: strings.TrimSuffix(word, ":")})
+			} else {
+				tokens = append(tokens, token{kind: tokenWord, value: word})
+			}
+			i = end
+		}
+	}
+	return tokens
+}
+
+type occur int
+
+const (
+	occurShould occur = iota
+	occurMust
+	occurMustNot
+)
+
+type clause struct {
+	occur occur
+	query Query
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseBool parses clauses until the end of the query, or until the
+// closing parenthesis of a group. Words without a field search fieldName.
+func (p *parser) parseBool(group bool, fieldName string) Query {
+	var clauses []clause
+	next := occurShould
+	for {
+		t, ok := p.peek()
+		if !ok {
+			break
+		}
+		if t.kind == tokenClose {
+			if group {
+				p.pos++
+				break
+			}
+			// Ignore an unbalanced parenthesis
+			p.pos++
+			continue
+		}
+		if t.kind == tokenWord {
+			switch t.value {
+			case "AND", "&&":
+				// Both sides of AND are required
+				if n := len(clauses); n > 0 && clauses[n-1].occur == occurShould {
+					clauses[n-1].occur = occurMust
+				}
+				if next == occurShould {
+					next = occurMust
+				}
+				p.pos++
+				continue
+			case "OR", "||":
+				p.pos++
+				continue
+			case "NOT", "!":
+				next = occurMustNot
+				p.pos++
+				continue
+			}
+		}
+
+		c, ok := p.parseClause(fieldName)
+		if !ok {
+			continue
+		}
+		if c.occur == occurShould {
+			c.occur = next
+		}
+		clauses = append(clauses, c)
+		next = occurShould
+	}
+
+	if len(clauses) == 1 && clauses[0].occur != occurMustNot {
+		return clauses[0].query
+	}
+	var q Bool
+	for _, c := range clauses {
+		switch c.occur {
+		case occurMust:
+			q.Must = append(q.Must, c.query)
+		case occurMustNot:
+			q.MustNot = append(q.MustNot, c.query)
+		default:
+			q.Should = append(q.Should, c.query)
+		}
+	}
+	return q
+}
+
+// parseClause parses a single, optionally prefixed, clause
+func (p *parser) parseClause(fieldName string) (clause, bool) {
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokenOpen:
+		return clause{query: p.parseBool(true, fieldName)}, true
+	case tokenPhrase:
+		return clause{query: Phrase{Field: fieldName, Text: t.value}}, true
+	case tokenField:
+		if _, ok := p.peek(); !ok {
+			return clause{}, false
+		}
+		name, o := prefixOccur(t.value)
+		c, ok := p.parseClause(name)
+		c.occur = o
+		return c, ok
+	}
+
+	word, o := prefixOccur(t.value)
+	c := clause{occur: o}
+	if word == "" {
+		// A prefix directly followed by a phrase or a group
+		if _, ok := p.peek(); !ok {
+			return clause{}, false
+		}
+		inner, ok := p.parseClause(fieldName)
+		c.query = inner.query
+		return c, ok
+	}
+
+	if name, value, ok := strings.Cut(word, ":"); ok && IsField(name) {
+		fieldName, word = name, value
+	}
+	c.query = wordQuery(fieldName, word)
+	return c, true
+}
+
+// prefixOccur strips the + or - prefix of a word, returning the
+// occurrence it stands for
+func prefixOccur(word string) (string, occur) {
+	switch {
+	case strings.HasPrefix(word, "+"):
+		return word[1:], occurMust
+	case strings.HasPrefix(word, "-"):
+		return word[1:], occurMustNot
+	}
+	return word, occurShould
+}
+
+// wordQuery returns the query of a single word, which can be a wildcard
+// or a fuzzy term
+func wordQuery(fieldName, word string) Query {
+	if term, distance, ok := strings.Cut(word, "~"); ok {
+		fuzziness := AutoFuzziness
+		if n, err := strconv.Atoi(distance); err == nil {
+			fuzziness = n
+		}
+		return Fuzzy{Field: fieldName, Term: term, Fuzziness: fuzziness}
+	}
+	if strings.ContainsAny(word, "*?") {
+		if strings.HasSuffix(word, "*") && !strings.ContainsAny(word[:len(word)-1], "*?") {
+			return Prefix{Field: fieldName, Prefix: strings.TrimSuffix(word, "*")}
+		}
+		return Wildcard{Field: fieldName, Pattern: word}
+	}
+	return Match{Field: fieldName, Text: word}
+}
@@ -0,0 +1,142 @@
+package invindex
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+func TestParseQueryString(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+	}{
+		{"power", Match{Field: AllField, Text: "power"}},
+		{
+			"power trading",
+			Bool{Should: []Query{Match{Field: AllField, Text: "power"}, Match{Field: AllField, Text: "trading"}}},
+		},
+		{
+			"power AND trading",
+			Bool{Must: []Query{Match{Field: AllField, Text: "power"}, Match{Field: AllField, Text: "trading"}}},
+		},
+		{
+			"power OR trading",
+			Bool{Should: []Query{Match{Field: AllField, Text: "power"}, Match{Field: AllField, Text: "trading"}}},
+		},
+		{
+			"power NOT trading",
+			Bool{Should: []Query{Match{Field: AllField, Text: "power"}}, MustNot: []Query{Match{Field: AllField, Text: "trading"}}},
+		},
+		{
+			"+power -trading",
+			Bool{Must: []Query{Match{Field: AllField, Text: "power"}}, MustNot: []Query{Match{Field: AllField, Text: "trading"}}},
+		},
+		{
+			"power AND (trading OR gas)",
+			Bool{Must: []Query{
+				Match{Field: AllField, Text: "power"},
+				Bool{Should: []Query{Match{Field: AllField, Text: "trading"}, Match{Field: AllField, Text: "gas"}}},
+			}},
+		},
+		{
+			"subject:(power trading)",
+			Bool{Should: []Query{Match{Field: "subject", Text: "power"}, Match{Field: "subject", Text: "trading"}}},
+		},
+		{
+			"-subject:(power body:gas)",
+			Bool{MustNot: []Query{
+				Bool{Should: []Query{Match{Field: "subject", Text: "power"}, Match{Field: "body", Text: "gas"}}},
+			}},
+		},
+		{`"power trading"`, Phrase{Field: AllField, Text: "power trading"}},
+		{`subject:"power trading"`, Phrase{Field: "subject", Text: "power trading"}},
+		{
+			`+"power trading" -subject:"gas"`,
+			Bool{Must: []Query{Phrase{Field: AllField, Text: "power trading"}}, MustNot: []Query{Phrase{Field: "subject", Text: "gas"}}},
+		},
+		{"subject:power", Match{Field: "subject", Text: "power"}},
+		{"unknown:power", Match{Field: AllField, Text: "unknown:power"}},
+		{"pow*", Prefix{Field: AllField, Prefix: "pow"}},
+		{"p?w*r", Wildcard{Field: AllField, Pattern: "p?w*r"}},
+		{"from:*@enron.com", Wildcard{Field: "from", Pattern: "*@enron.com"}},
+		{"powr~", Fuzzy{Field: AllField, Term: "powr", Fuzziness: AutoFuzziness}},
+		{"subject:powr~1", Fuzzy{Field: "subject", Term: "powr", Fuzziness: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got := ParseQueryString(test.query, AllField)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPhraseAcrossValues(t *testing.T) {
+	ix := New()
+	ix.Add(
+		models.Email{XTo: []string{"Lay, Kenneth", "Skilling, Jeff"}, Subject: "Power trading"},
+		models.Email{XTo: []string{"Kenneth Skilling"}, Subject: "Trading power"},
+	)
+
+	tests := []struct {
+		field string
+		text  string
+		want  int
+	}{
+		{"x_to", "lay kenneth", 1},
+		{"x_to", "skilling jeff", 1},
+		// Only the second email holds both words in the same value
+		{"x_to", "kenneth skilling", 1},
+		{AllField, "kenneth skilling", 1},
+		{AllField, "jeff power", 0},
+		{"subject", "power trading", 1},
+		{"subject", "trading power", 1},
+		{"subject", "power", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field+":"+test.text, func(t *testing.T) {
+			if got := ix.Count(Phrase{Field: test.field, Text: test.text}); got != test.want {
+				t.Errorf("expected %d emails, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestStemmedFields(t *testing.T) {
+	ix := New()
+	ix.Add(
+		models.Email{Subject: "Trading of power", XTo: []string{"Trading Desk"}},
+		models.Email{Subject: "Power traders", Body: "The traded volumes"},
+	)
+
+	tests := []struct {
+		query Query
+		want  int
+	}{
+		{Match{Field: "subject", Text: "trades"}, 1},
+		{Match{Field: "body", Text: "trading"}, 1},
+		{Match{Field: "subject", Text: "the of"}, 0},
+		{Phrase{Field: "subject", Text: "trade of power"}, 1},
+		// A stop word leaves a gap any word fills
+		{Phrase{Field: "subject", Text: "trading the power"}, 1},
+		{Phrase{Field: "subject", Text: "trading power"}, 0},
+		// Other text fields and _all are not stemmed, like in Zinc
+		{Match{Field: "x_to", Text: "trade"}, 0},
+		{Match{Field: AllField, Text: "trade"}, 0},
+		{Match{Field: AllField, Text: "traded"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%#v", test.query), func(t *testing.T) {
+			if got := ix.Count(test.query); got != test.want {
+				t.Errorf("expected %d emails, got %d", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package localindex stores inverted indexes on disk, so that emails can
+// be searched without running ZincSearch. The loader writes the indexes
+// with `load-data --sink=local:/path` and the backend serves them with
+// SEARCH_BACKEND=local.
+package localindex
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DanielOsorio01/enron-email-search/back/invindex"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/storage"
+)
+
+// fileExt is the extension of the file holding an index
+const fileExt = ".idx"
+
+// Store is a directory holding one file per index
+type Store struct {
+	dir     string
+	mu      sync.Mutex
+	indexes map[string]*invindex.Index
+	// dirty lists the indexes written since they were opened
+	dirty map[string]bool
+}
+
+// Open opens the existing store in dir. It never creates anything, so that
+// a backend serving a wrong path fails instead of serving an empty store.
+func Open(dir string) (*Store, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local index directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("failed to open local index directory: %s is not a directory", dir)
+	}
+	return &Store{
+		dir:     dir,
+		indexes: map[string]*invindex.Index{},
+		dirty:   map[string]bool{},
+	}, nil
+}
+
+// Create opens the store in dir to write to it, creating the directory if
+// needed
+func Create(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local index directory: %w", err)
+	}
+	return Open(dir)
+}
+
+// Names returns the names of the indexes stored on disk
+func (s *Store) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local indexes: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), fileExt); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Index returns the named index, read from disk the first time it is
+// requested. An index that does not exist yet is empty.
+func (s *Store) Index(name string) (*invindex.Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index(name)
+}
+
+func (s *Store) index(name string) (*invindex.Index, error) {
+	if ix, ok := s.indexes[name]; ok {
+		return ix, nil
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		ix := invindex.New()
+		s.indexes[name] = ix
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local index %s: %w", name, err)
+	}
+	defer f.Close()
+
+	ix, err := invindex.Decode(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local index %s: %w", name, err)
+	}
+	s.indexes[name] = ix
+	return ix, nil
+}
+
+// path returns the file of an index, rejecting names that would escape
+// the directory of the store
+func (s *Store) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid local index name %q", name)
+	}
+	return filepath.Join(s.dir, name+fileExt), nil
+}
+
+// Write adds emails to the named index. The first write of a run starts a
+// fresh index, so that loading the same emails again replaces the file on
+// disk rather than duplicating them. They are saved to disk by Close.
+func (s *Store) Write(ctx context.Context, index string, emails []models.Email) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.path(index); err != nil {
+		return err
+	}
+	if !s.dirty[index] {
+		s.indexes[index] = invindex.New()
+	}
+	s.indexes[index].Add(emails...)
+	s.dirty[index] = true
+	return nil
+}
+
+// Close saves the indexes written to disk. Each file is replaced at once,
+// so a backend reading the store never sees a partial index.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.dirty {
+		if err := s.save(name); err != nil {
+			return err
+		}
+		delete(s.dirty, name)
+	}
+	return nil
+}
+
+func (s *Store) save(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+name+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := s.indexes[name].Encode(w); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	// Temporary files are private, the backend may run as another user
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save local index %s: %w", name, err)
+	}
+	return nil
+}
+
+// The loader writes local indexes through the storage interface
+var _ storage.Sink = (*Store)(nil)
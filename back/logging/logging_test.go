@@ -0,0 +1,77 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
+)
+
+func TestRequestIDReachesZincAndLogs(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&logs, slog.LevelDebug))
+	defer slog.SetDefault(previous)
+
+	var zincRequestID string
+	zinc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zincRequestID = r.Header.Get(logging.RequestIDHeader)
+		w.Write([]byte(`{}`))
+	}))
+	defer zinc.Close()
+	client := db.NewZincClient(zinc.URL, "admin", "secret")
+
+	router := chi.NewRouter()
+	router.Use(logging.RequestIDMiddleware)
+	router.Use(logging.Middleware)
+	router.Get("/emails/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := client.ESSearch(r.Context(), "enron_emails", nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for _, sent := range []string{"", "caller-id-1", "bad id\n"} {
+		logs.Reset()
+		request := httptest.NewRequest(http.MethodGet, "/emails/42", nil)
+		if sent != "" {
+			request.Header.Set(logging.RequestIDHeader, sent)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		id := recorder.Header().Get(logging.RequestIDHeader)
+		switch {
+		case sent == "caller-id-1" && id != sent:
+			t.Errorf("expected the caller's request ID to be kept, got %q", id)
+		case sent != "caller-id-1" && (id == "" || id == sent):
+			t.Errorf("expected a new request ID for %q, got %q", sent, id)
+		}
+		if zincRequestID != id {
+			t.Errorf("expected Zinc to receive request ID %q, got %q", id, zincRequestID)
+		}
+
+		// One record for the Zinc call and one for the request, both with the ID
+		var messages []string
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("expected JSON log records, got %q", line)
+			}
+			if record["request_id"] != id {
+				t.Errorf("expected record %q to carry request ID %q", line, id)
+			}
+			messages = append(messages, record["msg"].(string))
+		}
+		if strings.Join(messages, ",") != "zinc request,request" {
+			t.Errorf("expected a zinc request and a request record, got %v", messages)
+		}
+	}
+}
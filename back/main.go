@@ -2,15 +2,41 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
 
 	"github.com/DanielOsorio01/enron-email-search/back/app"
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
 )
 
 func main() {
-	app := app.New(*app.LoadConfig())
+	// Log as JSON from the start, at the configured level once it is read
+	logging.Setup(os.Stdout, slog.LevelInfo)
+	config, err := app.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if config.PrintConfig {
+		if err := config.Print(os.Stdout); err != nil {
+			slog.Error("failed to print configuration", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	logging.SetLevel(config.LogLevel())
+
+	app, err := app.New(*config)
+	if err != nil {
+		slog.Error("failed to create app", "error", err)
+		os.Exit(1)
+	}
 
 	// Define a context that will be canceled when a SIGINT is sent
 	// to have graceful shutdown
@@ -18,8 +44,9 @@ func main() {
 	defer cancel()
 
 	// Start the app
-	err := app.Start(ctx)
+	err = app.Start(ctx)
 	if err != nil {
-		fmt.Println("failed to start app:", err)
+		slog.Error("failed to start app", "error", err)
+		os.Exit(1)
 	}
 }
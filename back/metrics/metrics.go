@@ -0,0 +1,156 @@
+// Package metrics collects the Prometheus metrics of the backend: the
+// requests it serves, the requests it sends to Zinc and its caches.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/DanielOsorio01/enron-email-search/back/cache"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+)
+
+// Namespace prefixes the name of every metric
+const Namespace = "enron"
+
+// Metrics holds the collectors of an app. Each app has its own registry, so
+// that several apps can run in the same process, as they do in tests.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	zincRequests    *prometheus.CounterVec
+	zincDuration    *prometheus.HistogramVec
+	zincRetries     *prometheus.CounterVec
+}
+
+// New creates the collectors, along with the Go runtime and process ones
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "http_requests_total",
+			Help:      "Requests served, by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent serving requests, by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		zincRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "zinc_requests_total",
+			Help:      "Requests sent to ZincSearch, by operation and outcome (ok, not_found, timeout, error, circuit_open or canceled).",
+		}, []string{"operation", "outcome"}),
+		zincDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "zinc_request_duration_seconds",
+			Help:      "Time spent on requests to ZincSearch including retries, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		zincRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "zinc_retries_total",
+			Help:      "Requests to ZincSearch sent again after a failure, by operation.",
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.zincRequests,
+		m.zincDuration,
+		m.zincRetries,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times the requests served by a chi router. Routes
+// are labeled with their pattern, such as /people/{id}/profile, so that ids
+// do not create a series each.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := prometheus.Labels{"route": route, "method": r.Method, "status": strconv.Itoa(status)}
+		m.requests.With(labels).Inc()
+		m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveZinc records a request sent to Zinc, it is meant for the Observe
+// option of the database client
+func (m *Metrics) ObserveZinc(call db.Call) {
+	m.zincRequests.WithLabelValues(call.Operation, outcome(call.Err)).Inc()
+	m.zincDuration.WithLabelValues(call.Operation).Observe(call.Duration.Seconds())
+	if call.Attempts > 1 {
+		m.zincRetries.WithLabelValues(call.Operation).Add(float64(call.Attempts - 1))
+	}
+}
+
+// RegisterCache exposes the hits and misses of a cache. The hit rate is
+// rate(enron_cache_hits_total) over the sum of both rates.
+func (m *Metrics) RegisterCache(name string, stats func() cache.Stats) {
+	labels := prometheus.Labels{"cache": name}
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "cache_hits_total",
+			Help:        "Lookups answered by a cache.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "cache_misses_total",
+			Help:        "Lookups a cache could not answer.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Misses) }),
+	)
+}
+
+// outcome classifies the error of a request to Zinc
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, db.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case db.IsNotFound(err):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Dataset is a mailbox corpus that can be searched, such as Enron.
+// PeopleMetricsIndex holds the network metrics of its people, it is empty
+// until the corpus is analyzed.
+type Dataset struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Index              string     `json:"index"`
+	PeopleMetricsIndex string     `json:"people_metrics_index,omitempty"`
+	Description        string     `json:"description,omitempty"`
+	CreatedAt          *time.Time `json:"created_at,omitempty"`
+	Documents          int        `json:"documents"`
+}
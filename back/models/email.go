@@ -24,6 +24,16 @@ type Email struct {
 	XOrigin                 string    `json:"x_origin,omitempty"`
 	XFileName               string    `json:"x_filename,omitempty"`
 	Body                    string    `json:"body"`
+	FromPhonetic            []string  `json:"from_phonetic,omitempty"`
+	RecipientsPhonetic      []string  `json:"recipients_phonetic,omitempty"`
+}
+
+// NameMatch is an email found by a name search, with the sender or
+// recipient name that matched and its similarity to the query
+type NameMatch struct {
+	Email
+	MatchedName string  `json:"matched_name"`
+	Score       float64 `json:"score"`
 }
 
 // PrintEmail prints the content of an Email struct.
@@ -0,0 +1,7 @@
+package models
+
+// FacetCount is the number of matching emails sharing a field value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Check is the outcome of one readiness check
+type Check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// IndexStatus describes the index the emails are searched in
+type IndexStatus struct {
+	// Name is the configured name, Index the index it resolves to through
+	// an alias, the same name without one
+	Name       string     `json:"name"`
+	Index      string     `json:"index"`
+	Documents  int        `json:"documents"`
+	LastIngest *time.Time `json:"last_ingest,omitempty"`
+}
+
+// Readiness is the report of the readiness probe
+type Readiness struct {
+	Ready   bool         `json:"ready"`
+	Backend string       `json:"backend"`
+	Checks  []Check      `json:"checks"`
+	Index   *IndexStatus `json:"index,omitempty"`
+}
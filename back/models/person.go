@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// MonthlyCount is the number of messages in a calendar month
+type MonthlyCount struct {
+	Month time.Time `json:"month"`
+	Count int       `json:"count"`
+}
+
+// Correspondent is an address together with the number of messages exchanged with it
+type Correspondent struct {
+	Address string `json:"address"`
+	Count   int    `json:"count"`
+}
+
+// FolderCount is a mailbox folder together with the number of messages filed in it
+type FolderCount struct {
+	Folder string `json:"folder"`
+	Count  int    `json:"count"`
+}
+
+// PersonProfile summarizes the email activity of a single address.
+// ExternalRecipients counts the distinct addresses outside the internal
+// domain the person sent messages to, and ExternalSentShare is the share of
+// the sent messages addressed to no one in the internal domain.
+type PersonProfile struct {
+	ID                 string          `json:"id"`
+	SentTotal          int             `json:"sent_total"`
+	ReceivedTotal      int             `json:"received_total"`
+	SentPerMonth       []MonthlyCount  `json:"sent_per_month"`
+	ReceivedPerMonth   []MonthlyCount  `json:"received_per_month"`
+	TopRecipients      []Correspondent `json:"top_recipients"`
+	TopSenders         []Correspondent `json:"top_senders"`
+	TopFolders         []FolderCount   `json:"top_folders"`
+	FirstActivity      *time.Time      `json:"first_activity,omitempty"`
+	LastActivity       *time.Time      `json:"last_activity,omitempty"`
+	ExternalRecipients int             `json:"external_recipients"`
+	ExternalSentShare  float64         `json:"external_sent_share"`
+}
+
+// PersonMetrics holds the network metrics of one person within one period,
+// as computed offline by the load-data analyze command
+type PersonMetrics struct {
+	Person           string     `json:"person"`
+	Period           string     `json:"period"`
+	PeriodStart      *time.Time `json:"period_start,omitempty"`
+	PeriodEnd        *time.Time `json:"period_end,omitempty"`
+	PageRank         float64    `json:"pagerank"`
+	Betweenness      float64    `json:"betweenness"`
+	InDegree         int        `json:"in_degree"`
+	OutDegree        int        `json:"out_degree"`
+	DegreeCentrality float64    `json:"degree_centrality"`
+	Community        int        `json:"community"`
+	CommunitySize    int        `json:"community_size"`
+}
+
+// Community lists the members of the community a person belongs to
+type Community struct {
+	Person  PersonMetrics   `json:"person"`
+	Members []PersonMetrics `json:"members"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TimelineBucket is the number of messages in one interval of a timeline
+type TimelineBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// TimelineSeries is the timeline of the messages sharing a split key, such
+// as a sender or a custodian
+type TimelineSeries struct {
+	Key     string           `json:"key"`
+	Total   int              `json:"total"`
+	Buckets []TimelineBucket `json:"buckets"`
+}
+
+// Timeline is the message volume over time of the emails matching a query
+type Timeline struct {
+	Interval string           `json:"interval"`
+	TimeZone string           `json:"time_zone"`
+	Total    int              `json:"total"`
+	Buckets  []TimelineBucket `json:"buckets"`
+	Series   []TimelineSeries `json:"series,omitempty"`
+}
+
+// TrendPoint is the usage of a term within one interval
+type TrendPoint struct {
+	Start     time.Time `json:"start"`
+	Count     int       `json:"count"`
+	Total     int       `json:"total"`
+	Frequency float64   `json:"frequency"`
+}
+
+// TermTrend is the usage of a term over time
+type TermTrend struct {
+	Term   string       `json:"term"`
+	Total  int          `json:"total"`
+	Points []TrendPoint `json:"points"`
+}
+
+// Trends compares the usage of several terms over time. Frequencies are the
+// share of all emails of an interval that mention the term.
+type Trends struct {
+	Interval string      `json:"interval"`
+	TimeZone string      `json:"time_zone"`
+	Terms    []TermTrend `json:"terms"`
+}
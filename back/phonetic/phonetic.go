@@ -0,0 +1,82 @@
+package phonetic
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/antzucaro/matchr"
+
+	"github.com/DanielOsorio01/enron-email-search/back/spelling"
+)
+
+// NameWords splits a display name or an address into the lower-cased words
+// of the person's name. The domain of addresses and the Exchange path of X-
+// header names are dropped.
+func NameWords(name string) []string {
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// Codes returns the primary and alternate Double Metaphone codes of word
+func Codes(word string) []string {
+	if len(word) < 2 {
+		return nil
+	}
+	primary, alternate := matchr.DoubleMetaphone(word)
+	if alternate == "" || alternate == primary {
+		return []string{primary}
+	}
+	return []string{primary, alternate}
+}
+
+// Similarity scores how close a name is to the query, between 0 and 1. Each
+// query word is paired with the name word it resembles most; half of the
+// word score comes from sharing a Double Metaphone code and half from the
+// edit distance between the spellings.
+func Similarity(query, name string) float64 {
+	queryWords := NameWords(query)
+	nameWords := NameWords(name)
+	if len(queryWords) == 0 || len(nameWords) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, q := range queryWords {
+		best := 0.0
+		for _, n := range nameWords {
+			if score := wordSimilarity(q, n); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	return total / float64(len(queryWords))
+}
+
+func wordSimilarity(a, b string) float64 {
+	score := 0.0
+	if sharesCode(a, b) {
+		score += 0.5
+	}
+	longest := max(len([]rune(a)), len([]rune(b)))
+	score += 0.5 * (1 - float64(spelling.Distance(a, b))/float64(longest))
+	return score
+}
+
+func sharesCode(a, b string) bool {
+	for _, ca := range Codes(a) {
+		for _, cb := range Codes(b) {
+			if ca == cb {
+				return true
+			}
+		}
+	}
+	return false
+}
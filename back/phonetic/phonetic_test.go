@@ -0,0 +1,74 @@
+package phonetic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Jeff Skilling", []string{"jeff", "skilling"}},
+		{"Lay, Kenneth", []string{"lay", "kenneth"}},
+		{"kenneth.lay@enron.com", []string{"kenneth", "lay"}},
+		{"Lay, Kenneth </O=ENRON/OU=NA/CN=RECIPIENTS/CN=KLAY>", []string{"lay", "kenneth"}},
+		{"O'Neal-Smith, J.", []string{"o", "neal", "smith", "j"}},
+		{"Kevin M. Presto 2", []string{"kevin", "m", "presto"}},
+		{"@enron.com", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NameWords(test.name); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCodes(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"smith", []string{"SM0", "XMT"}},
+		{"schmidt", []string{"XMT", "SMT"}},
+		{"kenneth", []string{"KN0", "KNT"}},
+		{"k", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.word, func(t *testing.T) {
+			if got := Codes(test.word); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name        string
+		query, with string
+		min, max    float64
+	}{
+		{"same name", "Jeff Skilling", "skilling, jeff", 1, 1},
+		{"address", "jeff skilling", "jeff.skilling@enron.com", 1, 1},
+		{"sounds alike", "Jeff Skiling", "Jeff Skilling", 0.9, 0.99},
+		{"spelled differently", "Smith", "Schmidt", 0.5, 0.9},
+		{"unrelated", "Sherri Sera", "Kevin Presto", 0, 0.4},
+		{"empty query", "", "Jeff Skilling", 0, 0},
+		{"empty name", "Jeff Skilling", "", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Similarity(test.query, test.with); got < test.min || got > test.max {
+				t.Errorf("expected a similarity between %f and %f, got %f", test.min, test.max, got)
+			}
+		})
+	}
+}
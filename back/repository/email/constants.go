@@ -10,6 +10,8 @@ const (
 	SearchTypeWildcard    = "wildcard"
 	SearchTypeFuzzy       = "fuzzy"
 	SearchTypeDateRange   = "daterange"
+	SearchTypePhonetic    = "phonetic"
+	SearchTypeProximity   = "proximity"
 )
 
 // Default values
@@ -17,3 +19,75 @@ const (
 	DefaultMaxResults = 20
 	DefaultFrom       = 0
 )
+
+// Aggregation limits
+const (
+	DefaultTopN           = 10
+	MaxAggregationBuckets = 10000
+)
+
+// InternalDomain is the mail domain considered internal to the organization
+const InternalDomain = "enron.com"
+
+// ScanPageSize is the number of documents fetched per request when scanning
+// every email that matches a query
+const ScanPageSize = 1000
+
+// MaxScanResults caps the number of emails visited by a single scan
+const MaxScanResults = 100000
+
+// Timeline intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// Fields a timeline can be split by
+const (
+	SplitSender    = "sender"
+	SplitCustodian = "custodian"
+)
+
+// MaxTrendTerms is the maximum number of terms compared in a single trend request
+const MaxTrendTerms = 10
+
+// Concordance limits
+const (
+	DefaultContextWords = 5
+	MaxContextWords     = 50
+	MaxConcordanceLines = 100000
+)
+
+// Related terms limits
+const (
+	DefaultRelatedHits = 200
+	MaxRelatedHits     = 1000
+	MaxRelatedTerms    = 100
+)
+
+// Spelling suggestions are added to searches with fewer hits than SuggestionThreshold
+const (
+	SuggestionThreshold = 3
+	MaxSuggestions      = 5
+)
+
+// Autocomplete limits
+const (
+	DefaultAutocompleteLimit = 10
+	MaxAutocompleteLimit     = 50
+)
+
+// Name search limits. Candidates are fetched by phonetic code and reranked
+// in memory, dropping those less similar than MinNameSimilarity.
+const (
+	MaxNameCandidates = 1000
+	MinNameSimilarity = 0.6
+)
+
+// MaxProximityCandidates caps the number of emails containing both operands
+// of a proximity query that are checked for the distance between them
+const MaxProximityCandidates = 10000
+
+// Facet limits
+const MaxFacetSize = 1000
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RequiredFields lists the field types the repository relies on. They match
+// the mapping created by `load-data index create`: exact keyword addresses
+// for filters and aggregations, and a real date for ranges and histograms.
+var RequiredFields = map[string]string{
+	"date":                "date",
+	"from":                "keyword",
+	"to":                  "keyword",
+	"cc":                  "keyword",
+	"bcc":                 "keyword",
+	"subject":             "text",
+	"body":                "text",
+	"x_folder":            "keyword",
+	"x_origin":            "keyword",
+	"from_phonetic":       "keyword",
+	"recipients_phonetic": "keyword",
+}
+
+// CheckMapping verifies that the index exists and that its mapping is
+// compatible with the queries of the repository
+func (r *ZincsearchRepo) CheckMapping(ctx context.Context, index string) error {
+	index, err := r.resolve(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	mapping, err := r.Client.GetMapping(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for field, want := range RequiredFields {
+		got, ok := mapping.Properties[field]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is missing", field))
+		} else if got.Type != want {
+			problems = append(problems, fmt.Sprintf("%s is %s instead of %s", field, got.Type, want))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("index %s has an incompatible mapping, recreate it with `load-data index create`: %s",
+			index, strings.Join(problems, ", "))
+	}
+	return nil
+}
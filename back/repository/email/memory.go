@@ -0,0 +1,220 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/DanielOsorio01/enron-email-search/back/invindex"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// MemoryRepo searches emails held in inverted indexes in memory. It serves
+// the indexes of the local store, small corpora and tests, where running
+// ZincSearch is overkill.
+type MemoryRepo struct {
+	mu      sync.RWMutex
+	indexes map[string]*invindex.Index
+}
+
+// NewMemoryRepo creates a new instance of MemoryRepo without any index
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{indexes: map[string]*invindex.Index{}}
+}
+
+// Add indexes emails in the named index, creating it if needed
+func (r *MemoryRepo) Add(index string, emails ...models.Email) {
+	r.mu.Lock()
+	ix, ok := r.indexes[index]
+	if !ok {
+		ix = invindex.New()
+		r.indexes[index] = ix
+	}
+	r.mu.Unlock()
+
+	ix.Add(emails...)
+}
+
+// SetIndex serves an already built index under the given name, such as
+// an index of the local store
+func (r *MemoryRepo) SetIndex(name string, ix *invindex.Index) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexes[name] = ix
+}
+
+// LoadFile indexes the emails of a JSON lines file, one email per line,
+// in the named index and returns how many were read
+func (r *MemoryRepo) LoadFile(index, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open emails: %w", err)
+	}
+	defer f.Close()
+
+	var emails []models.Email
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var email models.Email
+		if err := json.Unmarshal(scanner.Bytes(), &email); err != nil {
+			return 0, fmt.Errorf("failed to parse email on line %d: %w", line, err)
+		}
+		emails = append(emails, email)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read emails: %w", err)
+	}
+
+	r.Add(index, emails...)
+	return len(emails), nil
+}
+
+// index returns the named index, an index that was never added to is empty
+func (r *MemoryRepo) index(ctx context.Context, name string) (*invindex.Index, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if ix, ok := r.indexes[name]; ok {
+		return ix, nil
+	}
+	return invindex.New(), nil
+}
+
+// Search returns a page of the emails matching params
+func (r *MemoryRepo) Search(ctx context.Context, index string, params SearchParams) ([]models.Email, error) {
+	ix, err := r.index(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ix.Search(MemoryQuery(params), invindex.Request{
+		From: params.From,
+		Size: params.MaxResults,
+		Sort: params.SortFields,
+	})
+
+	emails := make([]models.Email, 0, len(result.Emails))
+	for _, email := range result.Emails {
+		if len(params.SourceFields) > 0 {
+			if email, err = selectFields(email, params.SourceFields); err != nil {
+				return nil, err
+			}
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+// Get returns the email with the given message id
+func (r *MemoryRepo) Get(ctx context.Context, index, messageID string) (*models.Email, error) {
+	ix, err := r.index(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ix.Search(invindex.Term{Field: "message_id", Value: messageID}, invindex.Request{Size: 1})
+	if len(result.Emails) == 0 {
+		return nil, ErrNotFound
+	}
+	return &result.Emails[0], nil
+}
+
+// Count returns the number of emails matching params
+func (r *MemoryRepo) Count(ctx context.Context, index string, params SearchParams) (int, error) {
+	ix, err := r.index(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+	return ix.Count(MemoryQuery(params)), nil
+}
+
+// Facets counts the values of keyword fields among the emails matching params
+func (r *MemoryRepo) Facets(ctx context.Context, index string, params SearchParams, fields []string, size int) (map[string][]models.FacetCount, error) {
+	ix, err := r.index(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Facets(MemoryQuery(params), fields, size), nil
+}
+
+// MemoryQuery builds the inverted index query equivalent to a search of
+// the Zinc search API
+func MemoryQuery(params SearchParams) invindex.Query {
+	field := params.Field
+	if field == "" {
+		field = invindex.AllField
+	}
+
+	var query invindex.Query
+	switch params.SearchType {
+	case SearchTypeMatchAll, SearchTypeDateRange:
+		query = invindex.MatchAll{}
+	case SearchTypeMatchPhrase:
+		query = invindex.Phrase{Field: field, Text: params.Term}
+	case SearchTypeTerm:
+		query = invindex.Term{Field: field, Value: params.Term}
+	case SearchTypeQueryString:
+		query = invindex.ParseQueryString(params.Term, field)
+	case SearchTypePrefix:
+		query = invindex.Prefix{Field: field, Prefix: params.Term}
+	case SearchTypeWildcard:
+		query = invindex.Wildcard{Field: field, Pattern: params.Term}
+	case SearchTypeFuzzy:
+		query = invindex.Fuzzy{Field: field, Term: params.Term, Fuzziness: invindex.AutoFuzziness}
+	default:
+		query = invindex.Match{Field: field, Text: params.Term}
+	}
+
+	if !params.StartTime.IsZero() || !params.EndTime.IsZero() {
+		return invindex.Bool{Must: []invindex.Query{
+			query,
+			invindex.DateRange{Start: params.StartTime, End: params.EndTime},
+		}}
+	}
+	return query
+}
+
+// selectFields keeps only the given fields of an email, like the _source
+// option of a Zinc search
+func selectFields(email models.Email, fields []string) (models.Email, error) {
+	data, err := json.Marshal(email)
+	if err != nil {
+		return email, fmt.Errorf("failed to select fields: %w", err)
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return email, fmt.Errorf("failed to select fields: %w", err)
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	data, err = json.Marshal(selected)
+	if err != nil {
+		return email, fmt.Errorf("failed to select fields: %w", err)
+	}
+
+	var result models.Email
+	if err := json.Unmarshal(data, &result); err != nil {
+		return email, fmt.Errorf("failed to select fields: %w", err)
+	}
+	return result, nil
+}
+
+// Both backends serve the email handlers
+var (
+	_ EmailSearcher = (*ZincsearchRepo)(nil)
+	_ EmailSearcher = (*MemoryRepo)(nil)
+)
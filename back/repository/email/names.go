@@ -0,0 +1,74 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/phonetic"
+)
+
+// SearchNames finds the emails sent or received by people whose name
+// sounds like name, between start and end when they are set. Candidates
+// sharing a Double Metaphone code with the query are ranked by phonetic
+// and spelling similarity. It returns one page
+// of matches and the total number of matches. Only the first
+// MaxNameCandidates candidates are ranked, truncated reports whether more
+// matched the codes, in which case total is a lower bound.
+func (r *ZincsearchRepo) SearchNames(ctx context.Context, index, name string, start, end time.Time, from, maxResults int) (page []models.NameMatch, total int, truncated bool, err error) {
+	var queries []db.ESQuery
+	for _, word := range phonetic.NameWords(name) {
+		for _, code := range phonetic.Codes(word) {
+			queries = append(queries,
+				db.ESQuery{"match": db.ESQuery{"from_phonetic": code}},
+				db.ESQuery{"match": db.ESQuery{"recipients_phonetic": code}},
+			)
+		}
+	}
+	if len(queries) == 0 {
+		return []models.NameMatch{}, 0, false, nil
+	}
+
+	result, err := r.Aggregate(ctx, index, db.ESQuery{
+		"query": withinDates(db.AnyOf(queries...), start, end),
+		"size":  MaxNameCandidates,
+	})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to search names: %w", err)
+	}
+
+	matches := make([]models.NameMatch, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		e := hit.Source
+		candidates := []string{e.From, e.XFrom}
+		for _, list := range [][]string{e.To, e.Cc, e.Bcc, e.XTo, e.XCc, e.XBcc} {
+			candidates = append(candidates, list...)
+		}
+
+		match := models.NameMatch{Email: e}
+		for _, candidate := range candidates {
+			if score := phonetic.Similarity(name, candidate); score > match.Score {
+				match.Score = score
+				match.MatchedName = NormalizeAddress(candidate)
+			}
+		}
+		if match.Score >= MinNameSimilarity {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	total = len(matches)
+	truncated = result.Hits.Total.Value > MaxNameCandidates
+	if from >= total {
+		return []models.NameMatch{}, total, truncated, nil
+	}
+	last := min(from+maxResults, total)
+	return matches[from:last], total, truncated, nil
+}
@@ -0,0 +1,35 @@
+package email_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/phonetic"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestSearchNamesDateRange(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	codes := phonetic.Codes("skilling")
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", From: "jeff.skilling@enron.com", XFrom: "Jeff Skilling", FromPhonetic: codes, Date: time.Date(2000, 12, 1, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<2>", From: "jeff.skilling@enron.com", XFrom: "Jeff Skilling", FromPhonetic: codes, Date: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := email.NewZincsearchRepo(server.Client())
+
+	matches, total, _, err := repo.SearchNames(context.Background(), "enron_emails", "Skiling", time.Time{}, time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(matches) != 1 || matches[0].MessageID != "<1>" {
+		t.Errorf("expected only <1>, got %d matches: %+v", total, matches)
+	}
+}
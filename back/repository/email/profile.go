@@ -0,0 +1,207 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
+)
+
+// Aggregate runs an Elasticsearch compatible query and returns its hits and aggregations
+func (r *ZincsearchRepo) Aggregate(ctx context.Context, index string, query db.ESQuery) (result *AggregateResult, err error) {
+	ctx, span := startSpan(ctx, "Aggregate", index)
+	defer func() { tracing.End(span, err) }()
+
+	index, err = r.resolve(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.Client.ESSearch(ctx, index, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform aggregation: %w", err)
+	}
+
+	result = &AggregateResult{}
+	if err := decode(ctx, response, result); err != nil {
+		return nil, fmt.Errorf("failed to parse aggregation results: %w", err)
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("aggregation error: %s", result.Error)
+	}
+
+	return result, nil
+}
+
+// PersonProfile returns the activity summary of the person identified by address
+func (r *ZincsearchRepo) PersonProfile(ctx context.Context, index, address string) (*models.PersonProfile, error) {
+	address = NormalizeAddress(address)
+	profile := &models.PersonProfile{ID: address}
+
+	// Messages sent by the person
+	sent, err := r.Aggregate(ctx, index, db.ESQuery{
+		"size":  0,
+		"query": db.MatchPhrase("from", address),
+		"aggs": db.ESQuery{
+			"per_month": db.DateHistogramAgg("date", "month"),
+			"to":        db.TermsAgg("to", MaxAggregationBuckets),
+			"cc":        db.TermsAgg("cc", MaxAggregationBuckets),
+			"bcc":       db.TermsAgg("bcc", MaxAggregationBuckets),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate sent messages: %w", err)
+	}
+
+	// Messages received by the person
+	received, err := r.Aggregate(ctx, index, db.ESQuery{
+		"size":  0,
+		"query": addressedTo(address),
+		"aggs": db.ESQuery{
+			"per_month": db.DateHistogramAgg("date", "month"),
+			"from":      db.TermsAgg("from", MaxAggregationBuckets),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate received messages: %w", err)
+	}
+
+	// Every message the person took part in
+	activity, err := r.Aggregate(ctx, index, db.ESQuery{
+		"size":  0,
+		"query": db.AnyOf(db.MatchPhrase("from", address), addressedTo(address)),
+		"aggs": db.ESQuery{
+			"folders": db.TermsAgg("x_folder", DefaultTopN),
+			"first":   db.MetricAgg("min", "date"),
+			"last":    db.MetricAgg("max", "date"),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate activity: %w", err)
+	}
+
+	profile.SentTotal = sent.Hits.Total.Value
+	profile.ReceivedTotal = received.Hits.Total.Value
+	profile.SentPerMonth = monthlyCounts(sent.Aggregations["per_month"])
+	profile.ReceivedPerMonth = monthlyCounts(received.Aggregations["per_month"])
+
+	recipients := mergeCorrespondents(sent.Aggregations["to"], sent.Aggregations["cc"], sent.Aggregations["bcc"])
+	profile.TopRecipients = topCorrespondents(recipients, DefaultTopN)
+	profile.TopSenders = topCorrespondents(mergeCorrespondents(received.Aggregations["from"]), DefaultTopN)
+
+	// Share of the sent messages addressed to no one in the internal domain.
+	// Excluding the domain keeps the query small however many external
+	// addresses the person wrote to.
+	profile.ExternalRecipients = externalRecipients(sent.Aggregations["to"], sent.Aggregations["cc"], sent.Aggregations["bcc"])
+	if profile.SentTotal > 0 {
+		internal := "*@" + InternalDomain
+		sentExternal, err := r.Aggregate(ctx, index, db.ESQuery{
+			"size": 0,
+			"query": db.AllOfExcept(
+				[]db.ESQuery{
+					db.MatchPhrase("from", address),
+					db.AnyOf(db.Wildcard("to", "*"), db.Wildcard("cc", "*"), db.Wildcard("bcc", "*")),
+				},
+				db.Wildcard("to", internal), db.Wildcard("cc", internal), db.Wildcard("bcc", internal),
+			),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count external messages: %w", err)
+		}
+		profile.ExternalSentShare = float64(sentExternal.Hits.Total.Value) / float64(profile.SentTotal)
+	}
+
+	profile.TopFolders = make([]models.FolderCount, 0, DefaultTopN)
+	for _, bucket := range activity.Aggregations["folders"].Buckets {
+		profile.TopFolders = append(profile.TopFolders, models.FolderCount{
+			Folder: bucket.KeyString(),
+			Count:  bucket.DocCount,
+		})
+	}
+	if first, ok := activity.Aggregations["first"].TimeValue(); ok {
+		profile.FirstActivity = &first
+	}
+	if last, ok := activity.Aggregations["last"].TimeValue(); ok {
+		profile.LastActivity = &last
+	}
+
+	return profile, nil
+}
+
+// NormalizeAddress trims and lower-cases an email address
+func NormalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// IsInternalAddress reports whether address belongs to the internal domain
+func IsInternalAddress(address string) bool {
+	return strings.HasSuffix(NormalizeAddress(address), "@"+InternalDomain)
+}
+
+func monthlyCounts(agg Aggregation) []models.MonthlyCount {
+	counts := make([]models.MonthlyCount, 0, len(agg.Buckets))
+	for _, bucket := range agg.Buckets {
+		month, err := bucket.KeyTime()
+		if err != nil {
+			continue
+		}
+		counts = append(counts, models.MonthlyCount{Month: month, Count: bucket.DocCount})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Month.Before(counts[j].Month)
+	})
+	return counts
+}
+
+// mergeCorrespondents sums the buckets of several terms aggregations by normalized address
+func mergeCorrespondents(aggs ...Aggregation) map[string]int {
+	counts := make(map[string]int)
+	for _, agg := range aggs {
+		for _, bucket := range agg.Buckets {
+			address := NormalizeAddress(bucket.KeyString())
+			if address == "" {
+				continue
+			}
+			counts[address] += bucket.DocCount
+		}
+	}
+	return counts
+}
+
+// externalRecipients counts the distinct recipient addresses of terms
+// aggregations outside the internal domain
+func externalRecipients(aggs ...Aggregation) int {
+	externals := make(map[string]bool)
+	for _, agg := range aggs {
+		for _, bucket := range agg.Buckets {
+			address := NormalizeAddress(bucket.KeyString())
+			if address == "" || IsInternalAddress(address) {
+				continue
+			}
+			externals[address] = true
+		}
+	}
+	return len(externals)
+}
+
+func topCorrespondents(counts map[string]int, n int) []models.Correspondent {
+	correspondents := make([]models.Correspondent, 0, len(counts))
+	for address, count := range counts {
+		correspondents = append(correspondents, models.Correspondent{Address: address, Count: count})
+	}
+	sort.Slice(correspondents, func(i, j int) bool {
+		if correspondents[i].Count != correspondents[j].Count {
+			return correspondents[i].Count > correspondents[j].Count
+		}
+		return correspondents[i].Address < correspondents[j].Address
+	})
+	if len(correspondents) > n {
+		correspondents = correspondents[:n]
+	}
+	return correspondents
+}
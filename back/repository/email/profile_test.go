@@ -0,0 +1,46 @@
+package email_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestPersonProfileExternalRecipients(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	date := time.Date(2001, 5, 14, 0, 0, 0, 0, time.UTC)
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", From: "jeff.skilling@enron.com", To: []string{"ken.lay@enron.com", "analyst@bank.com", "trader@fund.com"}, Date: date},
+		models.Email{MessageID: "<2>", From: "jeff.skilling@enron.com", To: []string{"ken.lay@enron.com"}, Date: date},
+		models.Email{MessageID: "<3>", From: "jeff.skilling@enron.com", To: []string{"ken.lay@enron.com"}, Cc: []string{"analyst@bank.com"}, Date: date},
+		models.Email{MessageID: "<4>", From: "ken.lay@enron.com", To: []string{"press@news.com"}, Date: date},
+		models.Email{MessageID: "<5>", From: "jeff.skilling@enron.com", To: []string{"trader@fund.com"}, Cc: []string{"press@news.com"}, Date: date},
+		models.Email{MessageID: "<6>", From: "jeff.skilling@enron.com", Date: date},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := email.NewZincsearchRepo(server.Client())
+
+	profile, err := repo.PersonProfile(context.Background(), "enron_emails", "jeff.skilling@enron.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.SentTotal != 5 {
+		t.Fatalf("expected 5 sent emails, got %d", profile.SentTotal)
+	}
+	if profile.ExternalRecipients != 3 {
+		t.Errorf("expected 3 external recipients, got %d", profile.ExternalRecipients)
+	}
+	// Only the fifth message is addressed to no one at Enron, the last one
+	// has no recipient at all
+	if want := 1.0 / 5.0; profile.ExternalSentShare != want {
+		t.Errorf("expected an external share of %f, got %f", want, profile.ExternalSentShare)
+	}
+}
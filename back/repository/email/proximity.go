@@ -0,0 +1,121 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Proximity is a query for two terms within Distance words of each other.
+// When Ordered is set the first term must come before the second.
+type Proximity struct {
+	First    string
+	Second   string
+	Distance int
+	Ordered  bool
+}
+
+// proximityPattern matches `"prepay" w/10 "Citibank"` (unordered) and
+// `prepay pre/10 citibank` (ordered). Operands may be quoted phrases.
+var proximityPattern = regexp.MustCompile(`(?i)^\s*(?:"([^"]+)"|(\S+))\s+(w|pre)/(\d+)\s+(?:"([^"]+)"|(\S+))\s*$`)
+
+// ParseProximity parses a proximity query, reporting whether term is one
+func ParseProximity(term string) (Proximity, bool) {
+	m := proximityPattern.FindStringSubmatch(term)
+	if m == nil {
+		return Proximity{}, false
+	}
+	distance, err := strconv.Atoi(m[4])
+	if err != nil || distance < 1 {
+		return Proximity{}, false
+	}
+	return Proximity{
+		First:    m[1] + m[2],
+		Second:   m[5] + m[6],
+		Distance: distance,
+		Ordered:  strings.EqualFold(m[3], "pre"),
+	}, true
+}
+
+// Matches reports whether text contains both terms within the distance.
+// The distance counts words from the end of one term to the start of the
+// other, so adjacent terms are at distance 1.
+func (p Proximity) Matches(text string) bool {
+	words := concordance.Tokenize(text)
+	firstWords := concordance.Tokenize(p.First)
+	secondWords := concordance.Tokenize(p.Second)
+
+	within := func(gap int) bool {
+		return gap >= 1 && gap <= p.Distance
+	}
+	for _, a := range occurrences(words, firstWords) {
+		for _, b := range occurrences(words, secondWords) {
+			if within(b - (a + len(firstWords) - 1)) {
+				return true
+			}
+			if !p.Ordered && within(a-(b+len(secondWords)-1)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// occurrences returns the start position of every occurrence of needle in words
+func occurrences(words, needle []string) []int {
+	var positions []int
+	if len(needle) == 0 {
+		return positions
+	}
+	for i := 0; i+len(needle) <= len(words); i++ {
+		found := true
+		for j, word := range needle {
+			if words[i+j] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// SearchProximity returns one page of the emails whose subject or body
+// match a proximity query between start and end, either of which can be
+// zero, and the total number of matches. ZincSearch has
+// no span queries and its phrase slop cannot enforce order, so emails
+// containing both terms are fetched and then filtered by word positions.
+// At most MaxProximityCandidates emails are checked; truncated is set when
+// more emails held both terms and the total only counts those checked.
+func (r *ZincsearchRepo) SearchProximity(ctx context.Context, index string, p Proximity, start, end time.Time, from, maxResults int) (emails []models.Email, total int, truncated bool, err error) {
+	query := withinDates(db.AllOf(
+		db.AnyOf(db.MatchPhrase("subject", p.First), db.MatchPhrase("body", p.First)),
+		db.AnyOf(db.MatchPhrase("subject", p.Second), db.MatchPhrase("body", p.Second)),
+	), start, end)
+
+	emails = []models.Email{}
+	candidates, err := r.Scan(ctx, index, query, nil, MaxProximityCandidates, func(hit SearchHitItem) error {
+		if !p.Matches(hit.Source.Subject) && !p.Matches(hit.Source.Body) {
+			return nil
+		}
+		if total >= from && len(emails) < maxResults {
+			emails = append(emails, hit.Source)
+		}
+		total++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to search proximity: %w", err)
+	}
+
+	return emails, total, candidates > MaxProximityCandidates, nil
+}
@@ -0,0 +1,88 @@
+package email_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestParseProximity(t *testing.T) {
+	tests := []struct {
+		term string
+		want email.Proximity
+		ok   bool
+	}{
+		{`"prepay" w/10 "Citibank"`, email.Proximity{First: "prepay", Second: "Citibank", Distance: 10}, true},
+		{"prepay pre/3 citibank", email.Proximity{First: "prepay", Second: "citibank", Distance: 3, Ordered: true}, true},
+		{`"off balance sheet" W/5 raptor`, email.Proximity{First: "off balance sheet", Second: "raptor", Distance: 5}, true},
+		{"  prepay   w/2   citibank  ", email.Proximity{First: "prepay", Second: "citibank", Distance: 2}, true},
+		{"prepay w/0 citibank", email.Proximity{}, false},
+		{"prepay w/x citibank", email.Proximity{}, false},
+		{"prepay w/10", email.Proximity{}, false},
+		{"prepay citibank", email.Proximity{}, false},
+		{"prepay w/2 citibank loans", email.Proximity{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.term, func(t *testing.T) {
+			got, ok := email.ParseProximity(test.term)
+			if ok != test.ok || got != test.want {
+				t.Errorf("expected %+v %v, got %+v %v", test.want, test.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestProximityMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		query email.Proximity
+		text  string
+		want  bool
+	}{
+		{"adjacent", email.Proximity{First: "prepay", Second: "citibank", Distance: 1}, "the prepay Citibank deal", true},
+		{"within", email.Proximity{First: "prepay", Second: "citibank", Distance: 3}, "prepay with the Citibank", true},
+		{"too far", email.Proximity{First: "prepay", Second: "citibank", Distance: 2}, "prepay with the Citibank", false},
+		{"unordered", email.Proximity{First: "prepay", Second: "citibank", Distance: 3}, "Citibank wants a prepay", true},
+		{"ordered", email.Proximity{First: "prepay", Second: "citibank", Distance: 3, Ordered: true}, "Citibank wants a prepay", false},
+		{"phrase", email.Proximity{First: "off balance sheet", Second: "raptor", Distance: 2}, "off balance sheet for Raptor", true},
+		{"phrase too far", email.Proximity{First: "off balance sheet", Second: "raptor", Distance: 1}, "off balance sheet for Raptor", false},
+		{"later occurrence", email.Proximity{First: "prepay", Second: "citibank", Distance: 1}, "prepay deals, the prepay Citibank signed", true},
+		{"missing term", email.Proximity{First: "prepay", Second: "citibank", Distance: 5}, "the prepay deal", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.query.Matches(test.text); got != test.want {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSearchProximityDateRange(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", Body: "the prepay with Citibank", Date: time.Date(2000, 12, 1, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<2>", Body: "another prepay for Citibank", Date: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := email.NewZincsearchRepo(server.Client())
+	query := email.Proximity{First: "prepay", Second: "citibank", Distance: 2}
+
+	emails, total, _, err := repo.SearchProximity(context.Background(), "enron_emails", query, time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(emails) != 1 || emails[0].MessageID != "<2>" {
+		t.Errorf("expected only <2>, got %d emails: %+v", total, emails)
+	}
+}
@@ -0,0 +1,77 @@
+package email
+
+import (
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+)
+
+// addressedTo matches documents that list address as a To, Cc or Bcc recipient
+func addressedTo(address string) db.ESQuery {
+	return db.AnyOf(
+		db.MatchPhrase("to", address),
+		db.MatchPhrase("cc", address),
+		db.MatchPhrase("bcc", address),
+	)
+}
+
+// FilterQuery builds the query used to select the emails matching a search
+// term within an optional date range. An empty term matches every email.
+func FilterQuery(term string, start, end time.Time) db.ESQuery {
+	queries := []db.ESQuery{}
+	if term != "" {
+		queries = append(queries, db.ESQuery{"query_string": db.ESQuery{"query": term}})
+	}
+	if !start.IsZero() || !end.IsZero() {
+		queries = append(queries, db.DateRange("date", start, end))
+	}
+	if len(queries) == 0 {
+		return db.ESQuery{"match_all": db.ESQuery{}}
+	}
+	return db.AllOf(queries...)
+}
+
+// SearchQuery builds the Elasticsearch compatible query equivalent to a
+// search of the Zinc search API, so that aggregations select the same
+// emails as the search
+func SearchQuery(params SearchParams) db.ESQuery {
+	field := params.Field
+	if field == "" {
+		field = "_all"
+	}
+
+	var query db.ESQuery
+	switch params.SearchType {
+	case SearchTypeMatchAll, SearchTypeDateRange:
+		query = db.ESQuery{"match_all": db.ESQuery{}}
+	case SearchTypeMatchPhrase:
+		query = db.MatchPhrase(field, params.Term)
+	case SearchTypeTerm:
+		query = db.ESQuery{"term": db.ESQuery{field: params.Term}}
+	case SearchTypeQueryString:
+		queryString := db.ESQuery{"query": params.Term}
+		if params.Field != "" {
+			queryString["default_field"] = params.Field
+		}
+		query = db.ESQuery{"query_string": queryString}
+	case SearchTypePrefix:
+		query = db.ESQuery{"prefix": db.ESQuery{field: params.Term}}
+	case SearchTypeWildcard:
+		query = db.ESQuery{"wildcard": db.ESQuery{field: params.Term}}
+	case SearchTypeFuzzy:
+		query = db.ESQuery{"fuzzy": db.ESQuery{field: params.Term}}
+	default:
+		query = db.ESQuery{"match": db.ESQuery{field: params.Term}}
+	}
+
+	return withinDates(query, params.StartTime, params.EndTime)
+}
+
+// withinDates restricts query to the emails sent between start and end.
+// Either bound can be zero.
+func withinDates(query db.ESQuery, start, end time.Time) db.ESQuery {
+	if start.IsZero() && end.IsZero() {
+		return query
+	}
+	return db.AllOf(query, db.DateRange("date", start, end))
+}
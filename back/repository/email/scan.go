@@ -0,0 +1,51 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
+)
+
+// Scan pages through every email matching query, calling fn for each one,
+// until limit emails have been visited. It returns the total number of
+// matching emails, which may be larger than the number visited.
+func (r *ZincsearchRepo) Scan(ctx context.Context, index string, query db.ESQuery, sourceFields []string, limit int, fn func(SearchHitItem) error) (total int, err error) {
+	ctx, span := startSpan(ctx, "Scan", index)
+	defer func() { tracing.End(span, err) }()
+
+	for from := 0; from < limit; from += ScanPageSize {
+		size := ScanPageSize
+		if from+size > limit {
+			size = limit - from
+		}
+
+		body := db.ESQuery{
+			"query": query,
+			"from":  from,
+			"size":  size,
+			"sort":  []string{"date"},
+		}
+		if len(sourceFields) > 0 {
+			body["_source"] = sourceFields
+		}
+
+		result, err := r.Aggregate(ctx, index, body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan emails: %w", err)
+		}
+		total = result.Hits.Total.Value
+
+		for _, hit := range result.Hits.Hits {
+			if err := fn(hit); err != nil {
+				return total, err
+			}
+		}
+
+		if len(result.Hits.Hits) < size || from+size >= total {
+			break
+		}
+	}
+	return total, nil
+}
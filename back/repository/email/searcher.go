@@ -0,0 +1,38 @@
+package email
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// EmailSearcher is a search backend for emails. ZincsearchRepo serves it
+// from ZincSearch and MemoryRepo from an inverted index held in memory.
+type EmailSearcher interface {
+	// Search returns a page of the emails matching params
+	Search(ctx context.Context, index string, params SearchParams) ([]models.Email, error)
+	// Get returns the email with the given message id
+	Get(ctx context.Context, index, messageID string) (*models.Email, error)
+	// Count returns the number of emails matching params
+	Count(ctx context.Context, index string, params SearchParams) (int, error)
+	// Facets counts the values of keyword fields among the emails matching
+	// params, returning the size most frequent values of each field
+	Facets(ctx context.Context, index string, params SearchParams, fields []string, size int) (map[string][]models.FacetCount, error)
+}
+
+// ErrNotFound is returned by Get when no email has the message id
+var ErrNotFound = errors.New("email not found")
+
+// FacetFields lists the keyword fields that can be counted by Facets
+var FacetFields = []string{"from", "to", "cc", "x_folder", "x_origin"}
+
+// IsFacetField reports whether field can be counted by Facets
+func IsFacetField(field string) bool {
+	for _, f := range FacetFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Status returns the index a name resolves to, with its document count and
+// the time documents were last ingested into it
+func (r *ZincsearchRepo) Status(ctx context.Context, index string) (*models.IndexStatus, error) {
+	resolved, err := r.resolve(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := r.Client.GetIndex(ctx, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of %s: %w", index, err)
+	}
+
+	status := &models.IndexStatus{
+		Name:      index,
+		Index:     resolved,
+		Documents: info.Stats.DocNum,
+	}
+	// Zinc versions before 0.3 do not report ingest times
+	if info.Stats.DocTimeMax > 0 {
+		last := time.UnixMicro(info.Stats.DocTimeMax).UTC()
+		status.LastIngest = &last
+	}
+	return status, nil
+}
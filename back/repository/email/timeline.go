@@ -0,0 +1,172 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// splitFields maps the split options of a timeline to the indexed field
+var splitFields = map[string]string{
+	SplitSender:    "from",
+	SplitCustodian: "x_origin",
+}
+
+// IsInterval reports whether interval is a supported timeline interval
+func IsInterval(interval string) bool {
+	return interval == IntervalDay || interval == IntervalWeek || interval == IntervalMonth
+}
+
+// IsSplit reports whether a timeline can be split by split
+func IsSplit(split string) bool {
+	_, ok := splitFields[split]
+	return ok
+}
+
+// DefaultTimelineParams returns default timeline parameters
+func DefaultTimelineParams() TimelineParams {
+	return TimelineParams{
+		Interval:  IntervalMonth,
+		Location:  time.UTC,
+		SplitSize: DefaultTopN,
+	}
+}
+
+// Timeline returns the number of emails matching the parameters per
+// interval, computed in the requested time zone. Intervals without emails
+// are included with a zero count.
+func (r *ZincsearchRepo) Timeline(ctx context.Context, index string, params TimelineParams) (*models.Timeline, error) {
+	if !IsInterval(params.Interval) {
+		return nil, fmt.Errorf("unsupported interval %q", params.Interval)
+	}
+	if params.Location == nil {
+		params.Location = time.UTC
+	}
+
+	histogram := db.DateHistogramAgg("date", params.Interval)
+	histogram["date_histogram"].(db.ESQuery)["time_zone"] = params.Location.String()
+
+	aggs := db.ESQuery{"timeline": histogram}
+	if params.Split != "" {
+		field, ok := splitFields[params.Split]
+		if !ok {
+			return nil, fmt.Errorf("unsupported split %q", params.Split)
+		}
+		split := db.TermsAgg(field, params.SplitSize)
+		split["aggs"] = db.ESQuery{"timeline": histogram}
+		aggs["split"] = split
+	}
+
+	result, err := r.Aggregate(ctx, index, db.ESQuery{
+		"size":  0,
+		"query": FilterQuery(params.Term, params.StartTime, params.EndTime),
+		"aggs":  aggs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate timeline: %w", err)
+	}
+
+	counts := bucketCounts(result.Aggregations["timeline"], params.Interval, params.Location)
+	first, last := params.StartTime, params.EndTime
+	if first.IsZero() || last.IsZero() {
+		minKey, maxKey := countsRange(counts)
+		if first.IsZero() {
+			first = minKey
+		}
+		if last.IsZero() {
+			last = maxKey
+		}
+	}
+
+	timeline := &models.Timeline{
+		Interval: params.Interval,
+		TimeZone: params.Location.String(),
+		Total:    result.Hits.Total.Value,
+		Buckets:  FillBuckets(counts, first, last, params.Interval, params.Location),
+	}
+
+	for _, bucket := range result.Aggregations["split"].Buckets {
+		series := models.TimelineSeries{
+			Key:   NormalizeAddress(bucket.KeyString()),
+			Total: bucket.DocCount,
+		}
+		seriesCounts := bucketCounts(bucket.Aggregations["timeline"], params.Interval, params.Location)
+		series.Buckets = FillBuckets(seriesCounts, first, last, params.Interval, params.Location)
+		timeline.Series = append(timeline.Series, series)
+	}
+
+	return timeline, nil
+}
+
+// FillBuckets returns one bucket per interval between first and last,
+// taking counts from the given map and zero for missing intervals. At most
+// MaxAggregationBuckets buckets are returned.
+func FillBuckets(counts map[time.Time]int, first, last time.Time, interval string, loc *time.Location) []models.TimelineBucket {
+	buckets := []models.TimelineBucket{}
+	if first.IsZero() || last.IsZero() {
+		return buckets
+	}
+
+	end := TruncateInterval(last, interval, loc)
+	for start := TruncateInterval(first, interval, loc); !start.After(end) && len(buckets) < MaxAggregationBuckets; start = nextInterval(start, interval) {
+		buckets = append(buckets, models.TimelineBucket{Start: start, Count: counts[start]})
+	}
+	return buckets
+}
+
+// TruncateInterval returns the start of the interval containing t in loc.
+// Weeks start on Monday.
+func TruncateInterval(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch interval {
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+func nextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// bucketCounts maps the start of every interval of a date histogram to its count
+func bucketCounts(agg Aggregation, interval string, loc *time.Location) map[time.Time]int {
+	counts := make(map[time.Time]int, len(agg.Buckets))
+	for _, bucket := range agg.Buckets {
+		key, err := bucket.KeyTime()
+		if err != nil || bucket.DocCount == 0 {
+			continue
+		}
+		counts[TruncateInterval(key, interval, loc)] += bucket.DocCount
+	}
+	return counts
+}
+
+func countsRange(counts map[time.Time]int) (time.Time, time.Time) {
+	keys := make([]time.Time, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Before(keys[j])
+	})
+	return keys[0], keys[len(keys)-1]
+}
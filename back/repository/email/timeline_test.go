@@ -0,0 +1,121 @@
+package email_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+func TestTruncateInterval(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip("time zone database unavailable:", err)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		interval string
+		loc      *time.Location
+		want     time.Time
+	}{
+		{"day", time.Date(2001, 5, 14, 17, 30, 0, 0, chicago), email.IntervalDay, chicago, time.Date(2001, 5, 14, 0, 0, 0, 0, chicago)},
+		// Daylight saving time starts at 2am, midnight is still standard time
+		{"day of the spring change", time.Date(2001, 4, 1, 12, 0, 0, 0, chicago), email.IntervalDay, chicago, time.Date(2001, 4, 1, 0, 0, 0, 0, chicago)},
+		{"day in the zone, not in UTC", time.Date(2001, 5, 15, 3, 0, 0, 0, time.UTC), email.IntervalDay, chicago, time.Date(2001, 5, 14, 0, 0, 0, 0, chicago)},
+		{"week starts on Monday", time.Date(2001, 5, 17, 9, 0, 0, 0, chicago), email.IntervalWeek, chicago, time.Date(2001, 5, 14, 0, 0, 0, 0, chicago)},
+		{"week of Sunday", time.Date(2001, 5, 20, 23, 0, 0, 0, chicago), email.IntervalWeek, chicago, time.Date(2001, 5, 14, 0, 0, 0, 0, chicago)},
+		// Daylight saving time ends on Sunday November 4th, after the week started
+		{"week across the fall change", time.Date(2001, 11, 4, 12, 0, 0, 0, chicago), email.IntervalWeek, chicago, time.Date(2001, 10, 29, 0, 0, 0, 0, chicago)},
+		{"week after the fall change", time.Date(2001, 11, 6, 3, 0, 0, 0, time.UTC), email.IntervalWeek, chicago, time.Date(2001, 11, 5, 0, 0, 0, 0, chicago)},
+		{"week in UTC", time.Date(2001, 11, 6, 3, 0, 0, 0, time.UTC), email.IntervalWeek, time.UTC, time.Date(2001, 11, 5, 0, 0, 0, 0, time.UTC)},
+		{"month across the spring change", time.Date(2001, 4, 20, 8, 0, 0, 0, chicago), email.IntervalMonth, chicago, time.Date(2001, 4, 1, 0, 0, 0, 0, chicago)},
+		{"month across the fall change", time.Date(2001, 11, 30, 23, 0, 0, 0, chicago), email.IntervalMonth, chicago, time.Date(2001, 11, 1, 0, 0, 0, 0, chicago)},
+		{"month in the zone, not in UTC", time.Date(2002, 1, 1, 3, 0, 0, 0, time.UTC), email.IntervalMonth, chicago, time.Date(2001, 12, 1, 0, 0, 0, 0, chicago)},
+		{"month in UTC", time.Date(2002, 1, 1, 3, 0, 0, 0, time.UTC), email.IntervalMonth, time.UTC, time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := email.TruncateInterval(test.t, test.interval, test.loc); !got.Equal(test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFillBuckets(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip("time zone database unavailable:", err)
+	}
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, chicago)
+	}
+
+	tests := []struct {
+		name        string
+		counts      map[time.Time]int
+		first, last time.Time
+		interval    string
+		want        []time.Time
+		wantCounts  []int
+	}{
+		{
+			name:       "weeks across the fall change",
+			counts:     map[time.Time]int{day(2001, 10, 29): 2, day(2001, 11, 12): 3},
+			first:      day(2001, 10, 30),
+			last:       day(2001, 11, 14),
+			interval:   email.IntervalWeek,
+			want:       []time.Time{day(2001, 10, 29), day(2001, 11, 5), day(2001, 11, 12)},
+			wantCounts: []int{2, 0, 3},
+		},
+		{
+			name:       "months across the spring change",
+			counts:     map[time.Time]int{day(2001, 3, 1): 4, day(2001, 5, 1): 1},
+			first:      day(2001, 3, 10),
+			last:       day(2001, 5, 31),
+			interval:   email.IntervalMonth,
+			want:       []time.Time{day(2001, 3, 1), day(2001, 4, 1), day(2001, 5, 1)},
+			wantCounts: []int{4, 0, 1},
+		},
+		{
+			name:       "days without counts",
+			counts:     map[time.Time]int{},
+			first:      day(2001, 11, 3),
+			last:       day(2001, 11, 5),
+			interval:   email.IntervalDay,
+			want:       []time.Time{day(2001, 11, 3), day(2001, 11, 4), day(2001, 11, 5)},
+			wantCounts: []int{0, 0, 0},
+		},
+		{
+			name:     "no range",
+			counts:   map[time.Time]int{day(2001, 3, 1): 4},
+			interval: email.IntervalMonth,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buckets := email.FillBuckets(test.counts, test.first, test.last, test.interval, chicago)
+			if len(buckets) != len(test.want) {
+				t.Fatalf("expected %d buckets, got %d: %v", len(test.want), len(buckets), buckets)
+			}
+			for i, bucket := range buckets {
+				// Buckets start at midnight in the zone whatever its offset
+				if !bucket.Start.Equal(test.want[i]) || bucket.Count != test.wantCounts[i] {
+					t.Errorf("expected bucket %d to start at %v with %d emails, got %v with %d", i, test.want[i], test.wantCounts[i], bucket.Start, bucket.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestFillBucketsIsLimited(t *testing.T) {
+	first := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 0, 2*email.MaxAggregationBuckets)
+	if got := len(email.FillBuckets(nil, first, last, email.IntervalDay, time.UTC)); got != email.MaxAggregationBuckets {
+		t.Errorf("expected %d buckets, got %d", email.MaxAggregationBuckets, got)
+	}
+}
@@ -0,0 +1,29 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
+)
+
+// tracer creates the spans of the repository
+var tracer = otel.Tracer(tracing.Name + "/repository/email")
+
+// startSpan starts the span of a repository method on an index
+func startSpan(ctx context.Context, method, index string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "email."+method, trace.WithAttributes(attribute.String("index", index)))
+}
+
+// decode parses a Zinc response in a span of its own, so that a trace
+// tells the time spent decoding large results from the request itself
+func decode(ctx context.Context, data []byte, v interface{}) error {
+	_, span := tracer.Start(ctx, "decode", trace.WithAttributes(attribute.Int("bytes", len(data))))
+	err := json.Unmarshal(data, v)
+	tracing.End(span, err)
+	return err
+}
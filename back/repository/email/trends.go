@@ -0,0 +1,71 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Trends returns, for every term, the share of all emails mentioning it per
+// interval. Every term is matched as a phrase so that terms such as
+// "mark-to-market" are not split into separate words.
+func (r *ZincsearchRepo) Trends(ctx context.Context, index string, terms []string, params TimelineParams) (*models.Trends, error) {
+	if len(terms) > MaxTrendTerms {
+		return nil, fmt.Errorf("at most %d terms can be compared", MaxTrendTerms)
+	}
+
+	// The timeline of every email is the baseline each term is normalized by
+	params.Term = ""
+	params.Split = ""
+	baseline, err := r.Timeline(ctx, index, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baseline timeline: %w", err)
+	}
+
+	trends := &models.Trends{
+		Interval: baseline.Interval,
+		TimeZone: baseline.TimeZone,
+		Terms:    make([]models.TermTrend, 0, len(terms)),
+	}
+
+	for _, term := range terms {
+		params.Term = phrase(term)
+		timeline, err := r.Timeline(ctx, index, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get timeline of %q: %w", term, err)
+		}
+
+		counts := make(map[time.Time]int, len(timeline.Buckets))
+		for _, bucket := range timeline.Buckets {
+			counts[bucket.Start] = bucket.Count
+		}
+
+		trend := models.TermTrend{
+			Term:   term,
+			Total:  timeline.Total,
+			Points: make([]models.TrendPoint, 0, len(baseline.Buckets)),
+		}
+		for _, bucket := range baseline.Buckets {
+			point := models.TrendPoint{
+				Start: bucket.Start,
+				Count: counts[bucket.Start],
+				Total: bucket.Count,
+			}
+			if point.Total > 0 {
+				point.Frequency = float64(point.Count) / float64(point.Total)
+			}
+			trend.Points = append(trend.Points, point)
+		}
+		trends.Terms = append(trends.Terms, trend)
+	}
+
+	return trends, nil
+}
+
+// phrase quotes term so that a query string matches it as a phrase
+func phrase(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `\"`) + `"`
+}
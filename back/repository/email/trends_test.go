@@ -0,0 +1,84 @@
+package email_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestTrends(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	month := func(m time.Month) time.Time { return time.Date(2001, m, 10, 0, 0, 0, 0, time.UTC) }
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", Body: "book the deal mark-to-market", Date: month(3)},
+		models.Email{MessageID: "<2>", Body: "lunch on friday", Date: month(3)},
+		models.Email{MessageID: "<3>", Body: "nothing to report", Date: month(4)},
+		models.Email{MessageID: "<4>", Body: "mark-to-market earnings", Date: month(5)},
+		models.Email{MessageID: "<5>", Body: "market to mark, not the same", Date: month(5)},
+		models.Email{MessageID: "<6>", Body: "raptor and mark-to-market", Date: month(5)},
+		models.Email{MessageID: "<7>", Body: "raptor again", Date: month(5)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := email.NewZincsearchRepo(server.Client())
+
+	trends, err := repo.Trends(context.Background(), "enron_emails", []string{"mark-to-market", "raptor"}, email.DefaultTimelineParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		term   string
+		total  int
+		counts []int
+		totals []int
+	}{
+		{"mark-to-market", 3, []int{1, 0, 2}, []int{2, 1, 4}},
+		// A month without the term still has a point, April has none at all
+		{"raptor", 2, []int{0, 0, 2}, []int{2, 1, 4}},
+	}
+
+	if len(trends.Terms) != len(tests) {
+		t.Fatalf("expected %d terms, got %d", len(tests), len(trends.Terms))
+	}
+	for i, test := range tests {
+		t.Run(test.term, func(t *testing.T) {
+			trend := trends.Terms[i]
+			if trend.Term != test.term || trend.Total != test.total {
+				t.Errorf("expected %q in %d emails, got %q in %d", test.term, test.total, trend.Term, trend.Total)
+			}
+			if len(trend.Points) != len(test.counts) {
+				t.Fatalf("expected %d points, got %d", len(test.counts), len(trend.Points))
+			}
+			for j, point := range trend.Points {
+				if want := month(time.Month(3+j)).AddDate(0, 0, -9); !point.Start.Equal(want) {
+					t.Errorf("expected point %d to start at %v, got %v", j, want, point.Start)
+				}
+				if point.Count != test.counts[j] || point.Total != test.totals[j] {
+					t.Errorf("expected point %d to count %d of %d emails, got %d of %d", j, test.counts[j], test.totals[j], point.Count, point.Total)
+				}
+				if want := float64(test.counts[j]) / float64(test.totals[j]); point.Frequency != want {
+					t.Errorf("expected point %d to have a frequency of %f, got %f", j, want, point.Frequency)
+				}
+			}
+		})
+	}
+}
+
+func TestTrendsTooManyTerms(t *testing.T) {
+	repo := email.NewZincsearchRepo(nil)
+	terms := make([]string, email.MaxTrendTerms+1)
+	for i := range terms {
+		terms[i] = "term"
+	}
+	if _, err := repo.Trends(context.Background(), "enron_emails", terms, email.DefaultTimelineParams()); err == nil {
+		t.Error("expected an error")
+	}
+}
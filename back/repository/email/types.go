@@ -1,6 +1,8 @@
 package email
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/DanielOsorio01/enron-email-search/back/models"
@@ -49,3 +51,122 @@ type SearchParams struct {
 	SortFields   []string
 	SourceFields []string
 }
+
+// AggregateResult represents the structure of an Elasticsearch compatible
+// ZincSearch response that includes aggregations
+type AggregateResult struct {
+	Took         int                    `json:"took"`
+	TimedOut     bool                   `json:"timed_out"`
+	Hits         SearchHits             `json:"hits"`
+	Aggregations map[string]Aggregation `json:"aggregations"`
+	Error        string                 `json:"error"`
+}
+
+// Aggregation contains the result of a single aggregation. Bucket
+// aggregations fill Buckets, metric aggregations fill Value.
+type Aggregation struct {
+	Value   json.RawMessage `json:"value"`
+	Buckets []Bucket        `json:"buckets"`
+}
+
+// Bucket represents a single bucket of a bucket aggregation
+type Bucket struct {
+	Key          interface{}            `json:"key"`
+	KeyAsString  string                 `json:"key_as_string"`
+	DocCount     int                    `json:"doc_count"`
+	Aggregations map[string]Aggregation `json:"-"`
+}
+
+// UnmarshalJSON decodes a bucket, collecting any sub-aggregations by name
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for name, raw := range fields {
+		var err error
+		switch name {
+		case "key":
+			err = json.Unmarshal(raw, &b.Key)
+		case "key_as_string":
+			err = json.Unmarshal(raw, &b.KeyAsString)
+		case "doc_count":
+			err = json.Unmarshal(raw, &b.DocCount)
+		default:
+			var agg Aggregation
+			if json.Unmarshal(raw, &agg) == nil {
+				if b.Aggregations == nil {
+					b.Aggregations = make(map[string]Aggregation)
+				}
+				b.Aggregations[name] = agg
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode bucket %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// KeyString returns the bucket key as a string
+func (b Bucket) KeyString() string {
+	if b.KeyAsString != "" {
+		return b.KeyAsString
+	}
+	return fmt.Sprint(b.Key)
+}
+
+// KeyTime returns the bucket key as a time. Date keys may be returned by
+// ZincSearch either as epoch milliseconds or as formatted strings.
+func (b Bucket) KeyTime() (time.Time, error) {
+	if b.KeyAsString != "" {
+		return parseAggTime(b.KeyAsString)
+	}
+	switch key := b.Key.(type) {
+	case float64:
+		return time.UnixMilli(int64(key)).UTC(), nil
+	case string:
+		return parseAggTime(key)
+	}
+	return time.Time{}, fmt.Errorf("unsupported bucket key %v", b.Key)
+}
+
+// TimeValue returns the value of a min or max aggregation on a date field
+func (a Aggregation) TimeValue() (time.Time, bool) {
+	var value interface{}
+	if len(a.Value) == 0 || json.Unmarshal(a.Value, &value) != nil {
+		return time.Time{}, false
+	}
+	switch v := value.(type) {
+	case float64:
+		if v <= 0 {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(v)).UTC(), true
+	case string:
+		t, err := parseAggTime(v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func parseAggTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date format %q", value)
+}
+
+// TimelineParams contains the parameters for a timeline request
+type TimelineParams struct {
+	Term      string
+	Interval  string
+	Location  *time.Location
+	StartTime time.Time
+	EndTime   time.Time
+	Split     string
+	SplitSize int
+}
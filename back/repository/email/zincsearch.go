@@ -2,15 +2,17 @@ package email
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
 	"github.com/DanielOsorio01/enron-email-search/back/db"
 	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
 )
 
 type ZincsearchRepo struct {
-	Client *db.ZincClient
+	Client  *db.ZincClient
+	Aliases *alias.Resolver
 }
 
 // NewZincsearchRepo creates a new instance of ZincsearchRepo
@@ -20,6 +22,15 @@ func NewZincsearchRepo(client *db.ZincClient) *ZincsearchRepo {
 	}
 }
 
+// resolve returns the index an index name points to. Without a resolver
+// names are used as they are.
+func (r *ZincsearchRepo) resolve(ctx context.Context, index string) (string, error) {
+	if r.Aliases == nil {
+		return index, nil
+	}
+	return r.Aliases.Resolve(ctx, index)
+}
+
 // DefaultSearchParams returns default search parameters
 func DefaultSearchParams() SearchParams {
 	return SearchParams{
@@ -32,12 +43,29 @@ func DefaultSearchParams() SearchParams {
 }
 
 // Search performs a search operation and returns matching emails
-func (r *ZincsearchRepo) Search(ctx context.Context, index string, params SearchParams) ([]models.Email, error) {
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+func (r *ZincsearchRepo) Search(ctx context.Context, index string, params SearchParams) (emails []models.Email, err error) {
+	ctx, span := startSpan(ctx, "Search", index)
+	defer func() { tracing.End(span, err) }()
+
+	if params.usesESQuery() {
+		result, err := r.Aggregate(ctx, index, esSearchBody(params))
+		if err != nil {
+			return nil, err
+		}
+		emails = make([]models.Email, 0, len(result.Hits.Hits))
+		for _, hit := range result.Hits.Hits {
+			emails = append(emails, hit.Source)
+		}
+		return emails, nil
+	}
+
+	index, err = r.resolve(ctx, index)
+	if err != nil {
+		return nil, err
 	}
 
 	response, err := r.Client.Search(
+		ctx,
 		index,
 		params.Term,
 		params.SearchType,
@@ -52,7 +80,7 @@ func (r *ZincsearchRepo) Search(ctx context.Context, index string, params Search
 	}
 
 	var searchResult SearchResult
-	if err := json.Unmarshal(response, &searchResult); err != nil {
+	if err := decode(ctx, response, &searchResult); err != nil {
 		return nil, fmt.Errorf("failed to parse search results: %w", err)
 	}
 
@@ -60,7 +88,7 @@ func (r *ZincsearchRepo) Search(ctx context.Context, index string, params Search
 		return nil, fmt.Errorf("search error: %s", searchResult.Error)
 	}
 
-	emails := make([]models.Email, 0, len(searchResult.Hits.Hits))
+	emails = make([]models.Email, 0, len(searchResult.Hits.Hits))
 	for _, hit := range searchResult.Hits.Hits {
 		emails = append(emails, hit.Source)
 	}
@@ -79,9 +107,33 @@ func (r *ZincsearchRepo) SearchWithDefaults(ctx context.Context, index, term str
 func (r *ZincsearchRepo) CountResults(ctx context.Context, index, term string) (int, error) {
 	params := DefaultSearchParams()
 	params.Term = term
+	return r.Count(ctx, index, params)
+}
+
+// Count returns the total number of documents matching the search parameters
+func (r *ZincsearchRepo) Count(ctx context.Context, index string, params SearchParams) (total int, err error) {
+	ctx, span := startSpan(ctx, "Count", index)
+	defer func() { tracing.End(span, err) }()
+
+	if params.usesESQuery() {
+		params.MaxResults = 0
+		result, err := r.Aggregate(ctx, index, esSearchBody(params))
+		if err != nil {
+			return 0, err
+		}
+		return result.Hits.Total.Value, nil
+	}
+
+	index, err = r.resolve(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+
+	params.From = 0
 	params.MaxResults = 0
 
 	response, err := r.Client.Search(
+		ctx,
 		index,
 		params.Term,
 		params.SearchType,
@@ -96,9 +148,82 @@ func (r *ZincsearchRepo) CountResults(ctx context.Context, index, term string) (
 	}
 
 	var searchResult SearchResult
-	if err := json.Unmarshal(response, &searchResult); err != nil {
+	if err := decode(ctx, response, &searchResult); err != nil {
 		return 0, fmt.Errorf("failed to parse count results: %w", err)
 	}
 
 	return searchResult.Hits.Total.Value, nil
 }
+
+// Get returns the email with the given message id
+func (r *ZincsearchRepo) Get(ctx context.Context, index, messageID string) (email *models.Email, err error) {
+	ctx, span := startSpan(ctx, "Get", index)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.Aggregate(ctx, index, db.ESQuery{
+		"query": db.ESQuery{"term": db.ESQuery{"message_id": messageID}},
+		"size":  1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Hits.Hits) == 0 {
+		return nil, ErrNotFound
+	}
+	return &result.Hits.Hits[0].Source, nil
+}
+
+// Facets counts the values of keyword fields among the emails matching params
+func (r *ZincsearchRepo) Facets(ctx context.Context, index string, params SearchParams, fields []string, size int) (facets map[string][]models.FacetCount, err error) {
+	ctx, span := startSpan(ctx, "Facets", index)
+	defer func() { tracing.End(span, err) }()
+
+	aggs := db.ESQuery{}
+	for _, field := range fields {
+		aggs[field] = db.TermsAgg(field, size)
+	}
+
+	result, err := r.Aggregate(ctx, index, db.ESQuery{
+		"query": SearchQuery(params),
+		"size":  0,
+		"aggs":  aggs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	facets = make(map[string][]models.FacetCount, len(fields))
+	for _, field := range fields {
+		buckets := result.Aggregations[field].Buckets
+		counts := make([]models.FacetCount, 0, len(buckets))
+		for _, bucket := range buckets {
+			counts = append(counts, models.FacetCount{Value: bucket.KeyString(), Count: bucket.DocCount})
+		}
+		facets[field] = counts
+	}
+	return facets, nil
+}
+
+// usesESQuery reports whether a search goes through the Elasticsearch
+// compatible API. The Zinc search API request sent by the client carries
+// neither a field nor a date range, and Zinc would filter dates on the
+// ingest time rather than on the date of the emails.
+func (p SearchParams) usesESQuery() bool {
+	return p.Field != "" || !p.StartTime.IsZero() || !p.EndTime.IsZero()
+}
+
+// esSearchBody builds the Elasticsearch compatible request of a search
+func esSearchBody(params SearchParams) db.ESQuery {
+	body := db.ESQuery{
+		"query": SearchQuery(params),
+		"from":  params.From,
+		"size":  params.MaxResults,
+	}
+	if len(params.SortFields) > 0 {
+		body["sort"] = params.SortFields
+	}
+	if len(params.SourceFields) > 0 {
+		body["_source"] = params.SourceFields
+	}
+	return body
+}
@@ -0,0 +1,109 @@
+package email_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+)
+
+func TestSearchFieldsAndDateRanges(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", Subject: "California power", Body: "Prices", Date: time.Date(2001, 5, 14, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<2>", Subject: "Lunch", Body: "Power trading after lunch", Date: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<3>", Subject: "Power trading", Body: "Desk", Date: time.Date(2001, 6, 2, 0, 0, 0, 0, time.UTC)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := email.NewZincsearchRepo(server.Client())
+
+	tests := []struct {
+		name   string
+		params func(*email.SearchParams)
+		want   []string
+		total  int
+	}{
+		{
+			name:   "every field",
+			params: func(p *email.SearchParams) {},
+			want:   []string{"<3>", "<2>", "<1>"},
+			total:  3,
+		},
+		{
+			name:   "one field",
+			params: func(p *email.SearchParams) { p.Field = "subject" },
+			want:   []string{"<3>", "<1>"},
+			total:  2,
+		},
+		{
+			name: "date range",
+			params: func(p *email.SearchParams) {
+				p.StartTime = time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)
+				p.EndTime = time.Date(2001, 6, 1, 23, 59, 59, 0, time.UTC)
+			},
+			want:  []string{"<2>"},
+			total: 1,
+		},
+		{
+			name: "field and date range",
+			params: func(p *email.SearchParams) {
+				p.Field = "subject"
+				p.StartTime = time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)
+			},
+			want:  []string{"<3>"},
+			total: 1,
+		},
+		{
+			name: "page of a field",
+			params: func(p *email.SearchParams) {
+				p.Field = "subject"
+				p.From = 1
+				p.MaxResults = 1
+			},
+			want:  []string{"<1>"},
+			total: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := email.DefaultSearchParams()
+			params.Term = "power"
+			params.SortFields = []string{"-date"}
+			test.params(&params)
+
+			emails, err := repo.Search(context.Background(), "enron_emails", params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range emails {
+				got = append(got, e.MessageID)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("expected %v, got %v", test.want, got)
+				}
+			}
+
+			// The total ignores the page
+			total, err := repo.Count(context.Background(), "enron_emails", params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != test.total {
+				t.Errorf("expected a total of %d, got %d", test.total, total)
+			}
+		})
+	}
+}
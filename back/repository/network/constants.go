@@ -0,0 +1,20 @@
+package network
+
+// Index holding the per-person metrics written by the load-data analyze command
+const Index = "enron_people_metrics"
+
+// PeriodAll is the name of the period spanning the whole corpus
+const PeriodAll = "all"
+
+// Centrality metrics people can be ranked by
+const (
+	MetricPageRank    = "pagerank"
+	MetricBetweenness = "betweenness"
+	MetricDegree      = "degree_centrality"
+)
+
+// Default values
+const (
+	DefaultLimit = 20
+	MaxLimit     = 1000
+)
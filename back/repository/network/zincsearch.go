@@ -0,0 +1,110 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+type ZincsearchRepo struct {
+	Client  *db.ZincClient
+	Aliases *alias.Resolver
+}
+
+// NewZincsearchRepo creates a new instance of ZincsearchRepo
+func NewZincsearchRepo(client *db.ZincClient) *ZincsearchRepo {
+	return &ZincsearchRepo{
+		Client: client,
+	}
+}
+
+// metricsResult represents the ZincSearch response for person metrics documents
+type metricsResult struct {
+	Hits struct {
+		Hits []struct {
+			Source models.PersonMetrics `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Error string `json:"error"`
+}
+
+// IsMetric reports whether people can be ranked by metric
+func IsMetric(metric string) bool {
+	return metric == MetricPageRank || metric == MetricBetweenness || metric == MetricDegree
+}
+
+// Central returns the limit people with the highest value of metric in period
+func (r *ZincsearchRepo) Central(ctx context.Context, index, period, metric string, limit int) ([]models.PersonMetrics, error) {
+	if !IsMetric(metric) {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+	return r.search(ctx, index, db.MatchPhrase("period", period), metric, limit)
+}
+
+// Get returns the metrics of person in period, or nil if there are none
+func (r *ZincsearchRepo) Get(ctx context.Context, index, person, period string) (*models.PersonMetrics, error) {
+	metrics, err := r.search(ctx, index, db.AllOf(db.MatchPhrase("person", person), db.MatchPhrase("period", period)), "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, nil
+	}
+	return &metrics[0], nil
+}
+
+// Community returns the person's metrics in period along with the most
+// central members of the community they belong to
+func (r *ZincsearchRepo) Community(ctx context.Context, index, person, period string, limit int) (*models.Community, error) {
+	metrics, err := r.Get(ctx, index, person, period)
+	if err != nil || metrics == nil {
+		return nil, err
+	}
+
+	members, err := r.search(ctx, index, db.AllOf(
+		db.MatchPhrase("period", period),
+		db.ESQuery{"term": db.ESQuery{"community": metrics.Community}},
+	), MetricPageRank, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Community{Person: *metrics, Members: members}, nil
+}
+
+func (r *ZincsearchRepo) search(ctx context.Context, index string, query db.ESQuery, sortMetric string, limit int) ([]models.PersonMetrics, error) {
+	if r.Aliases != nil {
+		var err error
+		if index, err = r.Aliases.Resolve(ctx, index); err != nil {
+			return nil, err
+		}
+	}
+
+	body := db.ESQuery{"query": query, "size": limit}
+	if sortMetric != "" {
+		body["sort"] = []db.ESQuery{{sortMetric: db.ESQuery{"order": "desc"}}}
+	}
+
+	response, err := r.Client.ESSearch(ctx, index, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search person metrics: %w", err)
+	}
+
+	var result metricsResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse person metrics: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("search error: %s", result.Error)
+	}
+
+	metrics := make([]models.PersonMetrics, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		metrics = append(metrics, hit.Source)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,248 @@
+package spelling
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+)
+
+// Lookup settings of the symmetric delete index. Only the first
+// PrefixLength characters of a word are indexed, which keeps the index
+// small while still catching most misspellings.
+const (
+	MaxDistance  = 2
+	PrefixLength = 7
+)
+
+// Dictionary suggests corrections for misspelled words using the symmetric
+// delete algorithm: every word is indexed under all the strings obtained by
+// deleting up to MaxDistance characters, so candidates for a misspelling are
+// found by looking up its own deletes.
+type Dictionary struct {
+	words   []string
+	counts  []int
+	known   map[string]int
+	deletes map[string][]int32
+}
+
+// Suggestion is a candidate correction
+type Suggestion struct {
+	Text     string `json:"text"`
+	Distance int    `json:"distance"`
+	Count    int    `json:"count"`
+}
+
+// Load reads a dictionary written by load-data with the -dictionary flag
+// and builds its lookup index
+func Load(path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer f.Close()
+
+	var file struct {
+		Words map[string]int `json:"words"`
+	}
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse dictionary: %w", err)
+	}
+	return New(file.Words), nil
+}
+
+// New builds a dictionary from words and their frequencies
+func New(words map[string]int) *Dictionary {
+	d := &Dictionary{
+		known:   make(map[string]int, len(words)),
+		deletes: make(map[string][]int32),
+	}
+	for word, count := range words {
+		id := int32(len(d.words))
+		d.words = append(d.words, word)
+		d.counts = append(d.counts, count)
+		d.known[word] = int(id)
+
+		for variant := range deletes(prefix(word)) {
+			d.deletes[variant] = append(d.deletes[variant], id)
+		}
+	}
+	return d
+}
+
+// Contains reports whether word is in the dictionary
+func (d *Dictionary) Contains(word string) bool {
+	_, ok := d.known[strings.ToLower(word)]
+	return ok
+}
+
+// Lookup returns up to n dictionary words within MaxDistance edits of word,
+// closest first and most frequent first among equally close words
+func (d *Dictionary) Lookup(word string, n int) []Suggestion {
+	word = strings.ToLower(word)
+	seen := make(map[int32]bool)
+	var suggestions []Suggestion
+
+	for variant := range deletes(prefix(word)) {
+		for _, id := range d.deletes[variant] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			candidate := d.words[id]
+			distance := Distance(word, candidate)
+			if distance > MaxDistance {
+				continue
+			}
+			suggestions = append(suggestions, Suggestion{Text: candidate, Distance: distance, Count: d.counts[id]})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// Suggest returns up to n corrections of a whole query. Words found in the
+// dictionary are kept, every other word is replaced by its closest
+// candidates. Stop words, numbers and query syntax such as field names,
+// wildcards and fuzzy terms are left as written, as the dictionary holds
+// none of them. Nothing is returned when no word needs correcting.
+func (d *Dictionary) Suggest(query string, n int) []Suggestion {
+	words := concordance.Words(query)
+
+	var checked []concordance.Word
+	var candidates [][]Suggestion
+	corrected := false
+	for _, word := range words {
+		if !concordance.IsIndexable(word.Text) || isSyntax(query, word) {
+			continue
+		}
+		checked = append(checked, word)
+		if id, ok := d.known[word.Text]; ok {
+			candidates = append(candidates, []Suggestion{{Text: word.Text, Count: d.counts[id]}})
+			continue
+		}
+		options := d.Lookup(word.Text, n)
+		if len(options) == 0 {
+			options = []Suggestion{{Text: word.Text}}
+		} else {
+			corrected = true
+		}
+		candidates = append(candidates, options)
+	}
+	if !corrected {
+		return nil
+	}
+
+	// The k-th suggestion uses the k-th candidate of every corrected word,
+	// written in place of the original so the rest of the query is kept
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	for k := 0; k < n; k++ {
+		var text strings.Builder
+		suggestion := Suggestion{}
+		last := 0
+		for i, options := range candidates {
+			option := options[min(k, len(options)-1)]
+			text.WriteString(query[last:checked[i].Start])
+			text.WriteString(option.Text)
+			last = checked[i].End
+			suggestion.Distance += option.Distance
+			if suggestion.Count == 0 || option.Count < suggestion.Count {
+				suggestion.Count = option.Count
+			}
+		}
+		text.WriteString(query[last:])
+		suggestion.Text = text.String()
+		if !seen[suggestion.Text] {
+			seen[suggestion.Text] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// isSyntax reports whether word is part of the query syntax rather than a
+// searched word: a field name, a wildcard pattern or a fuzzy term
+func isSyntax(query string, word concordance.Word) bool {
+	if word.End < len(query) && strings.ContainsRune(":*?~", rune(query[word.End])) {
+		return true
+	}
+	return word.Start > 0 && strings.ContainsRune("*?", rune(query[word.Start-1]))
+}
+
+func prefix(word string) string {
+	runes := []rune(word)
+	if len(runes) > PrefixLength {
+		runes = runes[:PrefixLength]
+	}
+	return string(runes)
+}
+
+// deletes returns word and every string obtained by deleting up to
+// MaxDistance of its characters
+func deletes(word string) map[string]bool {
+	variants := map[string]bool{word: true}
+	frontier := []string{word}
+	for distance := 0; distance < MaxDistance; distance++ {
+		var next []string
+		for _, w := range frontier {
+			runes := []rune(w)
+			if len(runes) <= 1 {
+				continue
+			}
+			for i := range runes {
+				variant := string(runes[:i]) + string(runes[i+1:])
+				if !variants[variant] {
+					variants[variant] = true
+					next = append(next, variant)
+				}
+			}
+		}
+		frontier = next
+	}
+	return variants
+}
+
+// Distance returns the Damerau-Levenshtein distance between a and b,
+// counting an adjacent transposition as a single edit
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,47 @@
+package spelling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	d := New(map[string]int{
+		"raptor":  40,
+		"raptors": 10,
+		"power":   300,
+		"tie":     20,
+		"ten":     30,
+		"subject": 50,
+		"ljm":     25,
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"raptor", nil},
+		{"raptr", []string{"raptor", "raptors"}},
+		{"the raptr", []string{"the raptor", "the raptors"}},
+		{"raptor AND powr", []string{"raptor AND power"}},
+		{"powr OR ljm", []string{"power OR ljm"}},
+		{"raptr 2001", []string{"raptor 2001", "raptors 2001"}},
+		{`"the powr desk"`, []string{`"the power desk"`}},
+		{"subjct:raptr", []string{"subjct:raptor", "subjct:raptors"}},
+		{"+raptr -(powr)", []string{"+raptor -(power)", "+raptors -(power)"}},
+		{"rapt* powr~", nil},
+		{"the to 2001", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			var got []string
+			for _, suggestion := range d.Suggest(test.query, 5) {
+				got = append(got, suggestion.Text)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Package storage defines the storage interface shared by the loader and
+// the backend. The loader writes emails to ZincSearch or to a local index
+// through a Sink, the backend searches them through an EmailSearcher.
+package storage
+
+import (
+	"context"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Sink stores the emails of an index
+type Sink interface {
+	// Write adds emails to the named index, creating it if needed
+	Write(ctx context.Context, index string, emails []models.Email) error
+	// Close flushes the written emails, the sink can't be used afterwards
+	Close() error
+}
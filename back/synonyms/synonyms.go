@@ -0,0 +1,180 @@
+package synonyms
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Expansion records the synonyms a term of a query was expanded to
+type Expansion struct {
+	Term     string   `json:"term"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// Set is a list of synonym groups. Every member of a group is expanded to
+// all the members of the group.
+type Set struct {
+	groups  map[string][]string
+	pattern *regexp.Regexp
+}
+
+// NewSet builds a synonym set from groups of equivalent terms
+func NewSet(groups [][]string) *Set {
+	s := &Set{groups: make(map[string][]string)}
+
+	var members []string
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		for _, member := range group {
+			key := strings.ToLower(strings.TrimSpace(member))
+			if key == "" {
+				continue
+			}
+			s.groups[key] = group
+			members = append(members, regexp.QuoteMeta(key))
+		}
+	}
+	if len(members) == 0 {
+		return s
+	}
+
+	// Longest members first so that "Kenneth Lay" wins over "Lay"
+	sort.Slice(members, func(i, j int) bool {
+		return len(members[i]) > len(members[j])
+	})
+	s.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(members, "|") + `)\b`)
+	return s
+}
+
+// Expand rewrites every term of a query string that belongs to a synonym
+// group into a disjunction of the whole group, such as
+// ("ENA" OR "Enron North America"). Terms inside quoted phrases, field
+// names and values, and wildcard or fuzzy terms are left alone as they
+// cannot hold a group. It returns the rewritten query and the expansions
+// that were applied.
+func (s *Set) Expand(query string) (string, []Expansion) {
+	if s.pattern == nil {
+		return query, nil
+	}
+
+	var b strings.Builder
+	var expansions []Expansion
+	last := 0
+	for _, loc := range s.pattern.FindAllStringIndex(query, -1) {
+		start, end := loc[0], loc[1]
+		if strings.Count(query[:start], `"`)%2 == 1 || isSyntax(query, start, end) {
+			continue
+		}
+		group, expansion := s.expand(query[start:end])
+		b.WriteString(query[last:start])
+		b.WriteString(group)
+		expansions = append(expansions, expansion)
+		last = end
+	}
+	b.WriteString(query[last:])
+	return b.String(), expansions
+}
+
+// ExpandText expands the synonyms of plain text searched with a match
+// query into a query string. Every other word is quoted so that none of
+// the text is read as query string syntax.
+func (s *Set) ExpandText(text string) (string, []Expansion) {
+	if s.pattern == nil {
+		return text, nil
+	}
+
+	var parts []string
+	var expansions []Expansion
+	last := 0
+	for _, loc := range s.pattern.FindAllStringIndex(text, -1) {
+		parts = append(parts, quoteWords(text[last:loc[0]])...)
+		group, expansion := s.expand(text[loc[0]:loc[1]])
+		parts = append(parts, group)
+		expansions = append(expansions, expansion)
+		last = loc[1]
+	}
+	if len(expansions) == 0 {
+		return text, nil
+	}
+	parts = append(parts, quoteWords(text[last:])...)
+	return strings.Join(parts, " "), expansions
+}
+
+// expand returns the disjunction of the group of match
+func (s *Set) expand(match string) (string, Expansion) {
+	group := s.groups[strings.ToLower(match)]
+
+	quoted := make([]string, len(group))
+	var others []string
+	for i, member := range group {
+		quoted[i] = `"` + member + `"`
+		if !strings.EqualFold(member, match) {
+			others = append(others, member)
+		}
+	}
+	return "(" + strings.Join(quoted, " OR ") + ")", Expansion{Term: match, Synonyms: others}
+}
+
+// isSyntax reports whether the term between start and end is a field name
+// or value, or part of a wildcard or fuzzy term
+func isSyntax(query string, start, end int) bool {
+	if end < len(query) && strings.ContainsRune(":*?~", rune(query[end])) {
+		return true
+	}
+	return start > 0 && strings.ContainsRune(":*?", rune(query[start-1]))
+}
+
+// quoteWords quotes every word of text, dropping the quotes it holds
+func quoteWords(text string) []string {
+	var quoted []string
+	for _, word := range strings.Fields(strings.ReplaceAll(text, `"`, " ")) {
+		quoted = append(quoted, `"`+word+`"`)
+	}
+	return quoted
+}
+
+// Store serves a synonym set loaded from a JSON file holding a list of
+// groups, such as [["ENA", "Enron North America"], ["Kenneth Lay", "Ken Lay", "klay"]].
+// The set can be reloaded while it is in use.
+type Store struct {
+	path string
+	set  atomic.Pointer[Set]
+}
+
+// Load reads the synonym file at path
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the synonym file again. The previous set is kept if the
+// file cannot be read.
+func (s *Store) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read synonyms: %w", err)
+	}
+
+	var groups [][]string
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return fmt.Errorf("failed to parse synonyms: %w", err)
+	}
+
+	s.set.Store(NewSet(groups))
+	return nil
+}
+
+// Set returns the current synonym set
+func (s *Store) Set() *Set {
+	return s.set.Load()
+}
@@ -0,0 +1,57 @@
+package synonyms
+
+import (
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	set := NewSet([][]string{{"ENA", "Enron North America"}, {"Kenneth Lay", "klay"}})
+
+	tests := []struct {
+		query string
+		want  string
+		terms int
+	}{
+		{"ENA trading", `("ENA" OR "Enron North America") trading`, 1},
+		{"kenneth lay AND ena", `("Kenneth Lay" OR "klay") AND ("ENA" OR "Enron North America")`, 2},
+		{`"ENA trading desk"`, `"ENA trading desk"`, 0},
+		{`"ENA desk" ENA`, `"ENA desk" ("ENA" OR "Enron North America")`, 1},
+		{"ena:power", "ena:power", 0},
+		{"subject:ENA", "subject:ENA", 0},
+		{"ENA* klay~", "ENA* klay~", 0},
+		{"enable", "enable", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got, expansions := set.Expand(test.query)
+			if got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+			if len(expansions) != test.terms {
+				t.Errorf("expected %d expansions, got %v", test.terms, expansions)
+			}
+		})
+	}
+}
+
+func TestExpandText(t *testing.T) {
+	set := NewSet([][]string{{"ENA", "Enron North America"}})
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"ENA trading desk", `("ENA" OR "Enron North America") "trading" "desk"`},
+		{`re: ENA (gas/power) -"desk"`, `"re:" ("ENA" OR "Enron North America") "(gas/power)" "-" "desk"`},
+		{"trading desk", "trading desk"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got, _ := set.ExpandText(test.text); got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
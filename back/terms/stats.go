@@ -0,0 +1,133 @@
+package terms
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+)
+
+// Ranking methods for related terms
+const (
+	MethodLLR = "llr"
+	MethodPMI = "pmi"
+)
+
+// MinSupport is the minimum number of hits a term must appear in to be
+// suggested, so that rare terms with inflated scores are left out
+const MinSupport = 2
+
+// Stats holds the corpus-wide document frequency of every term, as written
+// by load-data with the -term-stats flag
+type Stats struct {
+	Documents           int            `json:"documents"`
+	DocumentFrequencies map[string]int `json:"document_frequencies"`
+}
+
+// RelatedTerm is a term that co-occurs with a query, with its score
+type RelatedTerm struct {
+	Term       string  `json:"term"`
+	Score      float64 `json:"score"`
+	Hits       int     `json:"hits"`
+	Background int     `json:"background"`
+}
+
+// Load reads term statistics from a JSON file
+func Load(path string) (*Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open term statistics: %w", err)
+	}
+	defer f.Close()
+
+	var stats Stats
+	if err := json.NewDecoder(f).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to parse term statistics: %w", err)
+	}
+	return &stats, nil
+}
+
+// Related ranks the terms of the given documents by how strongly they are
+// associated with them compared to the whole corpus. Terms of the query
+// itself are excluded.
+func (s *Stats) Related(documents []string, query string, method string, limit int) []RelatedTerm {
+	exclude := make(map[string]bool)
+	for _, word := range concordance.Tokenize(query) {
+		exclude[word] = true
+	}
+
+	// Number of documents each known term appears in
+	foreground := make(map[string]int)
+	for _, doc := range documents {
+		seen := make(map[string]bool)
+		for _, word := range concordance.Tokenize(doc) {
+			if seen[word] || exclude[word] {
+				continue
+			}
+			seen[word] = true
+			if _, ok := s.DocumentFrequencies[word]; ok {
+				foreground[word]++
+			}
+		}
+	}
+
+	n := float64(len(documents))
+	total := float64(s.Documents)
+	related := make([]RelatedTerm, 0, len(foreground))
+	for word, hits := range foreground {
+		background := s.DocumentFrequencies[word]
+		if hits < MinSupport {
+			continue
+		}
+
+		var score float64
+		if method == MethodPMI {
+			score = math.Log2((float64(hits) / n) / (float64(background) / total))
+		} else {
+			score = logLikelihood(float64(hits), n, float64(background), total)
+		}
+		related = append(related, RelatedTerm{Term: word, Score: score, Hits: hits, Background: background})
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		return related[i].Term < related[j].Term
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
+
+// logLikelihood computes Dunning's log-likelihood ratio of a term appearing
+// in hits of n foreground documents and in background of total corpus
+// documents. Terms that are under-represented in the foreground get a
+// negative score so they sort last.
+func logLikelihood(hits, n, background, total float64) float64 {
+	// Contingency table of foreground against the rest of the corpus
+	k11 := hits
+	k12 := n - hits
+	k21 := math.Max(background-hits, 0)
+	k22 := math.Max(total-n-k21, 0)
+
+	score := 2 * (xlogx(k11) + xlogx(k12) + xlogx(k21) + xlogx(k22) -
+		xlogx(k11+k12) - xlogx(k21+k22) - xlogx(k11+k21) - xlogx(k12+k22) +
+		xlogx(k11+k12+k21+k22))
+
+	if k11/math.Max(k11+k12, 1) < k21/math.Max(k21+k22, 1) {
+		return -score
+	}
+	return score
+}
+
+func xlogx(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return x * math.Log(x)
+}
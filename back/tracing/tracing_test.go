@@ -0,0 +1,64 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/tracing"
+)
+
+func TestTraceReachesZinc(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), "test", tracing.ExporterNone); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	var traceparent string
+	zinc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{}`))
+	}))
+	defer zinc.Close()
+	client := db.NewZincClient(zinc.URL, "admin", "secret")
+
+	router := chi.NewRouter()
+	router.Use(tracing.Middleware)
+	router.Get("/emails/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := client.ESSearch(r.Context(), "enron_emails", nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The caller's trace is continued
+	request := httptest.NewRequest(http.MethodGet, "/emails/42", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Errorf("expected Zinc to receive the caller's trace, got traceparent %q", traceparent)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected a server and a client span, got %d", len(spans))
+	}
+	zincSpan, serverSpan := spans[0], spans[1]
+	if serverSpan.Name != "GET /emails/{id}" {
+		t.Errorf("expected the server span to be named after the route, got %q", serverSpan.Name)
+	}
+	if zincSpan.Name != "zinc.es_search" || zincSpan.Parent.SpanID() != serverSpan.SpanContext.SpanID() {
+		t.Errorf("expected the zinc.es_search span to be a child of the server span, got %q", zincSpan.Name)
+	}
+}
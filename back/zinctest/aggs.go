@@ -0,0 +1,224 @@
+package zinctest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// aggregate computes the supported aggregations over the matching
+// documents: terms, date_histogram and the min, max, value_count and
+// cardinality metrics. Bucket aggregations can hold sub-aggregations.
+func aggregate(sources []map[string]interface{}, aggs map[string]map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(aggs))
+	for name, agg := range aggs {
+		sub, err := subAggregations(agg)
+		if err != nil {
+			return nil, fmt.Errorf("aggregation %s: %w", name, err)
+		}
+
+		var value interface{}
+		for kind, raw := range agg {
+			if kind == "aggs" || kind == "aggregations" {
+				continue
+			}
+			params, _ := raw.(map[string]interface{})
+			field, _ := params["field"].(string)
+			switch kind {
+			case "terms":
+				size := 10
+				if n, ok := params["size"].(float64); ok {
+					size = int(n)
+				}
+				value, err = termsAgg(sources, field, size, sub)
+			case "date_histogram":
+				interval, _ := params["calendar_interval"].(string)
+				zone, _ := params["time_zone"].(string)
+				value, err = dateHistogramAgg(sources, field, interval, zone, sub)
+			case "min", "max", "value_count", "cardinality":
+				value = metricAgg(sources, kind, field)
+			default:
+				err = fmt.Errorf("unsupported aggregation %s", kind)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("aggregation %s: %w", name, err)
+			}
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// subAggregations returns the aggregations nested in a bucket aggregation
+func subAggregations(agg map[string]interface{}) (map[string]map[string]interface{}, error) {
+	raw, ok := agg["aggs"]
+	if !ok {
+		raw, ok = agg["aggregations"]
+	}
+	if !ok {
+		return nil, nil
+	}
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid sub-aggregations")
+	}
+	sub := make(map[string]map[string]interface{}, len(object))
+	for name, value := range object {
+		if sub[name], ok = value.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("invalid sub-aggregation %s", name)
+		}
+	}
+	return sub, nil
+}
+
+// bucket builds a bucket and computes its sub-aggregations
+func bucket(key interface{}, keyAsString string, sources []map[string]interface{}, sub map[string]map[string]interface{}) (map[string]interface{}, error) {
+	b := map[string]interface{}{"key": key, "doc_count": len(sources)}
+	if keyAsString != "" {
+		b["key_as_string"] = keyAsString
+	}
+	if len(sub) > 0 {
+		nested, err := aggregate(sources, sub)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range nested {
+			b[name] = value
+		}
+	}
+	return b, nil
+}
+
+func termsAgg(sources []map[string]interface{}, field string, size int, sub map[string]map[string]interface{}) (interface{}, error) {
+	groups := map[string][]map[string]interface{}{}
+	for _, source := range sources {
+		for _, value := range fieldStrings(source, field) {
+			groups[value] = append(groups[value], source)
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > size {
+		keys = keys[:size]
+	}
+
+	buckets := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		b, err := bucket(key, "", groups[key], sub)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return map[string]interface{}{"buckets": buckets}, nil
+}
+
+func dateHistogramAgg(sources []map[string]interface{}, field, interval, zone string, sub map[string]map[string]interface{}) (interface{}, error) {
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q", zone)
+		}
+	}
+
+	groups := map[int64][]map[string]interface{}{}
+	for _, source := range sources {
+		for _, value := range fieldStrings(source, field) {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				continue
+			}
+			start, err := truncate(t.In(loc), interval)
+			if err != nil {
+				return nil, err
+			}
+			groups[start.UnixMilli()] = append(groups[start.UnixMilli()], source)
+		}
+	}
+
+	keys := make([]int64, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	buckets := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		start := time.UnixMilli(key).In(loc)
+		b, err := bucket(key, start.Format(time.RFC3339), groups[key], sub)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return map[string]interface{}{"buckets": buckets}, nil
+}
+
+// truncate returns the start of the calendar interval holding t
+func truncate(t time.Time, interval string) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case "day", "1d":
+		return day, nil
+	case "week", "1w":
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case "month", "1M":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	case "year", "1y":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported calendar interval %q", interval)
+}
+
+// metricAgg computes a single value metric. Dates are compared as epoch
+// milliseconds, like Elasticsearch does.
+func metricAgg(sources []map[string]interface{}, kind, field string) interface{} {
+	var values []float64
+	count := 0
+	distinct := map[string]bool{}
+	for _, source := range sources {
+		for _, value := range fieldStrings(source, field) {
+			count++
+			distinct[value] = true
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				values = append(values, float64(t.UnixMilli()))
+				continue
+			}
+			var n float64
+			if _, err := fmt.Sscan(value, &n); err == nil {
+				values = append(values, n)
+			}
+		}
+	}
+
+	var value interface{}
+	switch kind {
+	case "value_count":
+		value = count
+	case "cardinality":
+		value = len(distinct)
+	case "min", "max":
+		if len(values) == 0 {
+			break
+		}
+		v := values[0]
+		for _, n := range values[1:] {
+			if (kind == "min" && n < v) || (kind == "max" && n > v) {
+				v = n
+			}
+		}
+		value = v
+	}
+	return map[string]interface{}{"value": value}
+}
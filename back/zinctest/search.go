@@ -0,0 +1,375 @@
+package zinctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/invindex"
+	"github.com/DanielOsorio01/enron-email-search/back/repository/email"
+)
+
+// search answers a request of the Zinc search API
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		SearchType string `json:"search_type"`
+		Query      struct {
+			Term  string `json:"term"`
+			Field string `json:"field"`
+		} `json:"query"`
+		SortFields []string `json:"sort_fields"`
+		From       int      `json:"from"`
+		MaxResults int      `json:"max_results"`
+		Source     []string `json:"_source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendError(w, "invalid search request", http.StatusBadRequest)
+		return
+	}
+
+	query := email.MemoryQuery(email.SearchParams{
+		SearchType: body.SearchType,
+		Term:       body.Query.Term,
+		Field:      body.Query.Field,
+	})
+	s.respond(w, chi.URLParam(r, "index"), query, invindex.Request{
+		From: body.From,
+		Size: body.MaxResults,
+		Sort: body.SortFields,
+	}, body.Source, nil)
+}
+
+// esSearch answers a request of the Elasticsearch compatible API
+func (s *Server) esSearch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query        map[string]interface{}            `json:"query"`
+		From         int                               `json:"from"`
+		Size         *int                              `json:"size"`
+		Sort         []interface{}                     `json:"sort"`
+		Source       []string                          `json:"_source"`
+		Aggs         map[string]map[string]interface{} `json:"aggs"`
+		Aggregations map[string]map[string]interface{} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendError(w, "invalid search request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query invindex.Query = invindex.MatchAll{}
+	if len(body.Query) > 0 {
+		var err error
+		if query, err = esQuery(body.Query); err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Elasticsearch returns 10 hits unless told otherwise
+	size := 10
+	if body.Size != nil {
+		size = *body.Size
+	}
+	sortFields, err := esSort(body.Sort)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	aggs := body.Aggs
+	if aggs == nil {
+		aggs = body.Aggregations
+	}
+
+	s.respond(w, chi.URLParam(r, "index"), query, invindex.Request{
+		From: body.From,
+		Size: size,
+		Sort: sortFields,
+	}, body.Source, aggs)
+}
+
+// respond runs a query against an index and sends its hits and aggregations
+func (s *Server) respond(w http.ResponseWriter, name string, query invindex.Query, req invindex.Request, sourceFields []string, aggs map[string]map[string]interface{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ix, ok := s.indexes[name]
+	if !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+
+	total, docs := ix.search.Find(query, req)
+	hits := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		source := ix.sources[doc]
+		if len(sourceFields) > 0 {
+			source = selectFields(source, sourceFields)
+		}
+		hits = append(hits, hit(name, ix.ids[doc], source))
+	}
+
+	response := map[string]interface{}{
+		"took":      0,
+		"timed_out": false,
+		"max_score": 1,
+		"hits": map[string]interface{}{
+			"total": map[string]int{"value": total},
+			"hits":  hits,
+		},
+	}
+	if len(aggs) > 0 {
+		_, matches := ix.search.Find(query, invindex.Request{Size: total})
+		sources := make([]map[string]interface{}, 0, len(matches))
+		for _, doc := range matches {
+			var source map[string]interface{}
+			json.Unmarshal(ix.sources[doc], &source)
+			sources = append(sources, source)
+		}
+		result, err := aggregate(sources, aggs)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response["aggregations"] = result
+	}
+
+	sendJSON(w, response, http.StatusOK)
+}
+
+// selectFields keeps only the given fields of a document
+func selectFields(source json.RawMessage, fields []string) json.RawMessage {
+	var all map[string]json.RawMessage
+	if json.Unmarshal(source, &all) != nil {
+		return source
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	data, _ := json.Marshal(selected)
+	return data
+}
+
+// esSort converts Elasticsearch sort clauses to invindex sort fields
+func esSort(clauses []interface{}) ([]string, error) {
+	var fields []string
+	for _, clause := range clauses {
+		switch c := clause.(type) {
+		case string:
+			fields = append(fields, c)
+		case map[string]interface{}:
+			for field, order := range c {
+				if o, ok := order.(map[string]interface{}); ok {
+					order = o["order"]
+				}
+				if order == "desc" {
+					field = "-" + field
+				}
+				fields = append(fields, field)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported sort %v", clause)
+		}
+	}
+	return fields, nil
+}
+
+// esQuery converts the supported subset of the Elasticsearch query DSL
+func esQuery(query map[string]interface{}) (invindex.Query, error) {
+	if len(query) != 1 {
+		return nil, fmt.Errorf("a query must have exactly one clause, got %d", len(query))
+	}
+	for kind, raw := range query {
+		if kind == "match_all" {
+			return invindex.MatchAll{}, nil
+		}
+		body, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s query", kind)
+		}
+
+		switch kind {
+		case "bool":
+			return esBool(body)
+		case "query_string":
+			text, _ := body["query"].(string)
+			field, _ := body["default_field"].(string)
+			if field == "" {
+				field = invindex.AllField
+			}
+			return invindex.ParseQueryString(text, field), nil
+		case "range":
+			return esRange(body)
+		case "terms":
+			field, values, err := fieldValue(body, "")
+			if err != nil {
+				return nil, err
+			}
+			list, _ := values.([]interface{})
+			if len(list) == 0 {
+				// An empty list matches nothing
+				return invindex.Term{Field: field}, nil
+			}
+			var should []invindex.Query
+			for _, value := range list {
+				should = append(should, invindex.Term{Field: field, Value: fmt.Sprint(value)})
+			}
+			return invindex.Bool{Should: should}, nil
+		}
+
+		field, value, err := fieldValue(body, map[string]string{
+			"match":        "query",
+			"match_phrase": "query",
+		}[kind])
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprint(value)
+		switch kind {
+		case "match":
+			return invindex.Match{Field: field, Text: text}, nil
+		case "match_phrase":
+			return invindex.Phrase{Field: field, Text: text}, nil
+		case "term":
+			return invindex.Term{Field: field, Value: text}, nil
+		case "prefix":
+			return invindex.Prefix{Field: field, Prefix: text}, nil
+		case "wildcard":
+			return invindex.Wildcard{Field: field, Pattern: text}, nil
+		case "fuzzy":
+			return invindex.Fuzzy{Field: field, Term: text, Fuzziness: invindex.AutoFuzziness}, nil
+		}
+		return nil, fmt.Errorf("unsupported query %s", kind)
+	}
+	return nil, nil
+}
+
+// fieldValue reads the single field of a leaf query and its value, given
+// either directly or in an object under key
+func fieldValue(body map[string]interface{}, key string) (string, interface{}, error) {
+	if len(body) != 1 {
+		return "", nil, fmt.Errorf("a leaf query must name exactly one field")
+	}
+	for field, value := range body {
+		if object, ok := value.(map[string]interface{}); ok {
+			if key == "" {
+				key = "value"
+			}
+			value = object[key]
+		}
+		if field == "" || value == nil {
+			return "", nil, fmt.Errorf("invalid query on field %q", field)
+		}
+		return field, value, nil
+	}
+	return "", nil, nil
+}
+
+// esBool converts a bool query, its clauses can be a query or a list
+func esBool(body map[string]interface{}) (invindex.Query, error) {
+	clauses := func(name string) ([]invindex.Query, error) {
+		var raw []interface{}
+		switch c := body[name].(type) {
+		case nil:
+			return nil, nil
+		case []interface{}:
+			raw = c
+		default:
+			raw = []interface{}{c}
+		}
+		queries := make([]invindex.Query, 0, len(raw))
+		for _, r := range raw {
+			clause, ok := r.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid bool %s clause", name)
+			}
+			q, err := esQuery(clause)
+			if err != nil {
+				return nil, err
+			}
+			queries = append(queries, q)
+		}
+		return queries, nil
+	}
+
+	var q invindex.Bool
+	var err error
+	if q.Must, err = clauses("must"); err != nil {
+		return nil, err
+	}
+	filter, err := clauses("filter")
+	if err != nil {
+		return nil, err
+	}
+	q.Must = append(q.Must, filter...)
+	if q.Should, err = clauses("should"); err != nil {
+		return nil, err
+	}
+	if q.MustNot, err = clauses("must_not"); err != nil {
+		return nil, err
+	}
+
+	// Should clauses only filter next to must clauses when required to
+	if minimum, _ := body["minimum_should_match"].(float64); minimum >= 1 && len(q.Must) > 0 && len(q.Should) > 0 {
+		q.Must = append(q.Must, invindex.Bool{Should: q.Should})
+	}
+	return q, nil
+}
+
+// esRange converts a range query, only ranges on the date of the emails
+// are supported
+func esRange(body map[string]interface{}) (invindex.Query, error) {
+	bounds, ok := body["date"].(map[string]interface{})
+	if len(body) != 1 || !ok {
+		return nil, fmt.Errorf("only date ranges are supported")
+	}
+
+	var q invindex.DateRange
+	for op, raw := range bounds {
+		value, _ := raw.(string)
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if op == "format" || op == "time_zone" {
+				continue
+			}
+			return nil, fmt.Errorf("invalid date %q", value)
+		}
+		switch op {
+		case "gte":
+			q.Start = t
+		case "gt":
+			q.Start = t.Add(time.Nanosecond)
+		case "lte":
+			q.End = t
+		case "lt":
+			q.End = t.Add(-time.Nanosecond)
+		}
+	}
+	return q, nil
+}
+
+// fieldStrings returns the values of a document field as strings
+func fieldStrings(source map[string]interface{}, field string) []string {
+	switch value := source[field].(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	default:
+		return []string{strings.TrimSpace(fmt.Sprint(value))}
+	}
+}
@@ -0,0 +1,506 @@
+// Package zinctest provides an in-process fake of the subset of the
+// ZincSearch API used by the backend and the loader, for tests that need
+// a database without running the Zinc container.
+//
+// Documents are kept in memory. Searches go through the inverted index of
+// the invindex package, so only the fields of the email mapping can be
+// searched. Other documents can still be fetched by id or with match_all.
+package zinctest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/invindex"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// Default credentials, the same as the defaults of the backend and the loader
+const (
+	DefaultUser     = "admin"
+	DefaultPassword = "Complexpass#123"
+)
+
+// Server is a fake ZincSearch listening on a local address
+type Server struct {
+	*httptest.Server
+	User     string
+	Password string
+
+	mu      sync.RWMutex
+	indexes map[string]*index
+	nextID  int
+}
+
+// index holds the documents of an index in the order they were added
+type index struct {
+	mappings json.RawMessage
+	ids      []string
+	sources  []json.RawMessage
+	byID     map[string]int
+	search   *invindex.Index
+	// firstIngest and lastIngest are the times documents were first and
+	// last added, reported in the index stats
+	firstIngest time.Time
+	lastIngest  time.Time
+}
+
+func newIndex() *index {
+	return &index{byID: map[string]int{}, search: invindex.New()}
+}
+
+// add appends a document, or replaces the document with the same id
+func (ix *index) add(id string, source json.RawMessage) {
+	ix.lastIngest = time.Now()
+	if ix.firstIngest.IsZero() {
+		ix.firstIngest = ix.lastIngest
+	}
+	if i, ok := ix.byID[id]; ok {
+		ix.sources[i] = source
+		ix.reindex()
+		return
+	}
+	ix.byID[id] = len(ix.ids)
+	ix.ids = append(ix.ids, id)
+	ix.sources = append(ix.sources, source)
+	ix.search.Add(decodeEmail(source))
+}
+
+// remove deletes the document with the given id, reporting whether it existed
+func (ix *index) remove(id string) bool {
+	i, ok := ix.byID[id]
+	if !ok {
+		return false
+	}
+	ix.ids = append(ix.ids[:i], ix.ids[i+1:]...)
+	ix.sources = append(ix.sources[:i], ix.sources[i+1:]...)
+	delete(ix.byID, id)
+	for j := i; j < len(ix.ids); j++ {
+		ix.byID[ix.ids[j]] = j
+	}
+	ix.reindex()
+	return true
+}
+
+// reindex rebuilds the inverted index after a document was replaced
+func (ix *index) reindex() {
+	ix.search = invindex.New()
+	for _, source := range ix.sources {
+		ix.search.Add(decodeEmail(source))
+	}
+}
+
+// decodeEmail reads the email fields of a document, documents of other
+// kinds decode to an empty email
+func decodeEmail(source json.RawMessage) models.Email {
+	var email models.Email
+	json.Unmarshal(source, &email)
+	return email
+}
+
+// NewServer starts a fake ZincSearch accepting the default credentials.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		User:     DefaultUser,
+		Password: DefaultPassword,
+		indexes:  map[string]*index{},
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// Client returns a database client connected to the server
+func (s *Server) Client() *db.ZincClient {
+	return db.NewZincClient(s.URL, s.User, s.Password)
+}
+
+// Count returns the number of documents in the named index
+func (s *Server) Count(name string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if ix, ok := s.indexes[name]; ok {
+		return len(ix.ids)
+	}
+	return 0
+}
+
+// Add stores documents in the named index, creating it if needed
+func (s *Server) Add(name string, docs ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range docs {
+		source, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+		s.index(name).add(s.newID(), source)
+	}
+	return nil
+}
+
+// index returns the named index, creating it if needed. The caller must
+// hold the write lock.
+func (s *Server) index(name string) *index {
+	ix, ok := s.indexes[name]
+	if !ok {
+		ix = newIndex()
+		s.indexes[name] = ix
+	}
+	return ix
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+func (s *Server) routes() http.Handler {
+	router := chi.NewRouter()
+
+	// The web UI answers without credentials
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(s.authenticate)
+
+		router.Post("/api/_bulk", s.bulk)
+		router.Post("/api/_bulkv2", s.bulkV2)
+		router.Get("/api/index", s.listIndexes)
+		router.Post("/api/index", s.createIndex)
+		router.Get("/api/index/{name}", s.getIndex)
+		router.Delete("/api/index/{name}", s.deleteIndex)
+		router.Get("/api/{index}/_mapping", s.getMapping)
+		router.Put("/api/{index}/_mapping", s.putMapping)
+		router.Post("/api/{index}/_search", s.search)
+		router.Get("/api/{index}/_doc/{id}", s.getDocument)
+		router.Put("/api/{index}/_doc/{id}", s.putDocument)
+		router.Delete("/api/{index}/_doc/{id}", s.deleteDocument)
+		router.Post("/es/{index}/_search", s.esSearch)
+	})
+
+	return router
+}
+
+// authenticate rejects requests without the credentials of the server
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != s.User || password != s.Password {
+			sendJSON(w, map[string]string{"auth": "Unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bulk applies newline delimited operations, each an action line such as
+// {"index": {"_index": "emails", "_id": "1"}} followed by the document
+// unless the action is a delete
+func (s *Server) bulk(w http.ResponseWriter, r *http.Request) {
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, 64<<20)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(line, &action); err != nil || len(action) != 1 {
+			sendError(w, "invalid bulk action", http.StatusBadRequest)
+			return
+		}
+		for name, meta := range action {
+			if meta.Index == "" {
+				sendError(w, "bulk action without an index", http.StatusBadRequest)
+				return
+			}
+			if name == "delete" {
+				if ix, ok := s.indexes[meta.Index]; ok {
+					ix.remove(meta.ID)
+				}
+				count++
+				continue
+			}
+			if !scanner.Scan() {
+				sendError(w, "bulk action without a document", http.StatusBadRequest)
+				return
+			}
+			source := json.RawMessage(bytes.Clone(scanner.Bytes()))
+			id := meta.ID
+			if id == "" {
+				id = s.newID()
+			}
+			s.index(meta.Index).add(id, source)
+			count++
+		}
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"message":      "bulk data inserted",
+		"record_count": count,
+	}, http.StatusOK)
+}
+
+func (s *Server) bulkV2(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Index   string            `json:"index"`
+		Records []json.RawMessage `json:"records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Index == "" {
+		sendError(w, "invalid bulkv2 request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	ix := s.index(body.Index)
+	for _, record := range body.Records {
+		ix.add(s.newID(), record)
+	}
+	s.mu.Unlock()
+
+	sendJSON(w, map[string]interface{}{
+		"message":      "v2 data inserted",
+		"record_count": len(body.Records),
+	}, http.StatusOK)
+}
+
+func (s *Server) createIndex(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string          `json:"name"`
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		sendError(w, "invalid index definition", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indexes[body.Name]; ok {
+		sendError(w, "index ["+body.Name+"] already exists", http.StatusBadRequest)
+		return
+	}
+	s.index(body.Name).mappings = body.Mappings
+
+	sendJSON(w, map[string]string{"message": "ok", "index": body.Name}, http.StatusOK)
+}
+
+func (s *Server) getIndex(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.indexes[name]
+	if !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+
+	sendJSON(w, ix.info(name), http.StatusOK)
+}
+
+// listIndexes answers a page of the indexes sorted by name, in the format
+// of Zinc 0.4
+func (s *Server) listIndexes(w http.ResponseWriter, r *http.Request) {
+	pageNum, _ := strconv.Atoi(r.URL.Query().Get("page_num"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.indexes))
+	for name := range s.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := []map[string]interface{}{}
+	for i := (pageNum - 1) * pageSize; i < len(names) && i < pageNum*pageSize; i++ {
+		list = append(list, s.indexes[names[i]].info(names[i]))
+	}
+	sendJSON(w, map[string]interface{}{
+		"list": list,
+		"page": map[string]int{"page_num": pageNum, "page_size": pageSize, "total": len(names)},
+	}, http.StatusOK)
+}
+
+// info describes the index as the index APIs do
+func (ix *index) info(name string) map[string]interface{} {
+	size := 0
+	for _, source := range ix.sources {
+		size += len(source)
+	}
+	return map[string]interface{}{
+		"name":         name,
+		"storage_type": "disk",
+		"shard_num":    1,
+		"mappings":     ix.mappingsOrEmpty(),
+		"stats": map[string]int64{
+			"doc_num":      int64(len(ix.ids)),
+			"storage_size": int64(size),
+			"doc_time_min": unixMicro(ix.firstIngest),
+			"doc_time_max": unixMicro(ix.lastIngest),
+		},
+	}
+}
+
+func (s *Server) deleteIndex(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indexes[name]; !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+	delete(s.indexes, name)
+
+	sendJSON(w, map[string]string{"message": "deleted", "index": name}, http.StatusOK)
+}
+
+func (s *Server) getMapping(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "index")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.indexes[name]
+	if !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+
+	sendJSON(w, map[string]interface{}{
+		name: map[string]interface{}{"mappings": ix.mappingsOrEmpty()},
+	}, http.StatusOK)
+}
+
+func (s *Server) putMapping(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "index")
+
+	var mappings json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&mappings); err != nil {
+		sendError(w, "invalid mapping", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.index(name).mappings = mappings
+	s.mu.Unlock()
+
+	sendJSON(w, map[string]string{"message": "ok"}, http.StatusOK)
+}
+
+func (ix *index) mappingsOrEmpty() json.RawMessage {
+	if len(ix.mappings) == 0 {
+		return json.RawMessage(`{"properties":{}}`)
+	}
+	return ix.mappings
+}
+
+func (s *Server) getDocument(w http.ResponseWriter, r *http.Request) {
+	name, id := chi.URLParam(r, "index"), chi.URLParam(r, "id")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.indexes[name]
+	if !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+	i, ok := ix.byID[id]
+	if !ok {
+		sendError(w, "id not found", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, hit(name, id, ix.sources[i]), http.StatusOK)
+}
+
+func (s *Server) putDocument(w http.ResponseWriter, r *http.Request) {
+	name, id := chi.URLParam(r, "index"), chi.URLParam(r, "id")
+
+	var source json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+		sendError(w, "invalid document", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.index(name).add(id, source)
+	s.mu.Unlock()
+
+	sendJSON(w, map[string]string{"message": "ok", "id": id}, http.StatusOK)
+}
+
+func (s *Server) deleteDocument(w http.ResponseWriter, r *http.Request) {
+	name, id := chi.URLParam(r, "index"), chi.URLParam(r, "id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ix, ok := s.indexes[name]
+	if !ok {
+		sendError(w, "index "+name+" does not exist", http.StatusBadRequest)
+		return
+	}
+	if !ix.remove(id) {
+		sendError(w, "id not found", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "deleted", "index": name, "id": id}, http.StatusOK)
+}
+
+// unixMicro returns the time as Zinc reports it, zero when unset
+func unixMicro(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro()
+}
+
+// hit is a document as returned by searches and document requests
+func hit(index, id string, source json.RawMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"_index":  index,
+		"_type":   "_doc",
+		"_id":     id,
+		"_score":  1,
+		"_source": source,
+	}
+}
+
+func sendJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func sendError(w http.ResponseWriter, message string, status int) {
+	sendJSON(w, map[string]string{"error": message}, status)
+}
@@ -0,0 +1,160 @@
+package zinctest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+func TestRejectsWrongCredentials(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := db.NewZincClient(server.URL, server.User, "wrong")
+	_, err := client.Search(context.Background(), "enron_emails", "power", "match", "", 0, 10, nil, nil)
+
+	var statusErr *db.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a 401 status error, got %v", err)
+	}
+}
+
+func TestSearchAndAggregate(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	err := server.Add("enron_emails",
+		models.Email{MessageID: "<1>", From: "jeff.skilling@enron.com", Subject: "California power", Date: time.Date(2001, 5, 14, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<2>", From: "ken.lay@enron.com", Subject: "Power trading", Date: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+		models.Email{MessageID: "<3>", From: "ken.lay@enron.com", Subject: "Lunch", Date: time.Date(2001, 6, 2, 0, 0, 0, 0, time.UTC)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := server.Client()
+
+	body, err := client.Search(context.Background(), "enron_emails", "power", "match", "", 0, 10, nil, []string{"-@timestamp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Hits struct {
+			Total struct{ Value int } `json:"total"`
+			Hits  []struct {
+				Source models.Email `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Hits.Total.Value != 2 || result.Hits.Hits[0].Source.MessageID != "<2>" {
+		t.Errorf("expected <2> then <1>, got %+v", result.Hits)
+	}
+
+	body, err = client.ESSearch(context.Background(), "enron_emails", db.ESQuery{
+		"query": db.ESQuery{"range": db.ESQuery{"date": db.ESQuery{"gte": "2001-06-01T00:00:00Z"}}},
+		"size":  0,
+		"aggs":  db.ESQuery{"senders": db.TermsAgg("from", 10)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var aggs struct {
+		Aggregations struct {
+			Senders struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int    `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"senders"`
+		} `json:"aggregations"`
+	}
+	if err := json.Unmarshal(body, &aggs); err != nil {
+		t.Fatal(err)
+	}
+	buckets := aggs.Aggregations.Senders.Buckets
+	if len(buckets) != 1 || buckets[0].Key != "ken.lay@enron.com" || buckets[0].DocCount != 2 {
+		t.Errorf("expected ken.lay@enron.com twice, got %+v", buckets)
+	}
+}
+
+func TestMissingIndex(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	_, err := server.Client().ESSearch(context.Background(), "missing", db.ESQuery{"query": db.ESQuery{"match_all": db.ESQuery{}}})
+	if !db.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	def := db.IndexDefinition{
+		Name:     "emails",
+		Mappings: db.Mappings{Properties: map[string]db.Property{"from": {Type: "keyword", Index: true}}},
+	}
+	if err := client.CreateIndex(ctx, def); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateIndex(ctx, def); err == nil {
+		t.Error("expected creating an existing index to fail")
+	}
+
+	n, err := client.BulkV2(ctx, "emails", []models.Email{{MessageID: "<1>"}, {MessageID: "<2>"}})
+	if err != nil || n != 2 {
+		t.Fatalf("bulkv2: got %d records, %v", n, err)
+	}
+	n, err = client.Bulk(ctx, "emails", []db.BulkOperation{
+		{Action: db.BulkIndex, ID: "a", Doc: models.Email{MessageID: "<a>"}},
+		{Action: db.BulkIndex, ID: "b", Doc: models.Email{MessageID: "<b>"}},
+		{Action: db.BulkDelete, ID: "a"},
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("bulk: got %d records, %v", n, err)
+	}
+	if err := client.DeleteDocument(ctx, "emails", "b"); err != nil {
+		t.Fatal(err)
+	}
+	var doc models.Email
+	if err := client.GetDocument(ctx, "emails", "b", &doc); !db.IsNotFound(err) {
+		t.Errorf("expected the deleted document to be missing, got %v", err)
+	}
+
+	if err := client.PutDocument(ctx, "settings", "theme", map[string]string{"color": "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PutMapping(ctx, "settings", db.Mappings{Properties: map[string]db.Property{"color": {Type: "keyword"}}}); err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := client.GetMapping(ctx, "settings")
+	if err != nil || mapping.Properties["color"].Type != "keyword" {
+		t.Errorf("expected the color mapping, got %+v, %v", mapping, err)
+	}
+
+	indexes, err := client.ListIndexes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 2 || indexes[0].Name != "emails" || indexes[0].Stats.DocNum != 2 {
+		t.Errorf("expected emails with 2 documents and settings, got %+v", indexes)
+	}
+
+	if err := client.DeleteIndex(ctx, "emails"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := client.IndexExists(ctx, "emails"); err != nil || exists {
+		t.Errorf("expected the deleted index to be missing, got %v, %v", exists, err)
+	}
+}
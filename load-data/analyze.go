@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+	"github.com/DanielOsorio01/enron-email-search/load-data/index"
+	"github.com/DanielOsorio01/enron-email-search/load-data/network"
+)
+
+// analyze computes the network metrics of every person for the whole corpus
+// and for every year and quarter, and posts them to the people metrics index.
+// With -dataset they are posted to the metrics index of a registered dataset,
+// which is recorded in its registry document.
+func analyze(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: load-data analyze <folder> [flags]")
+		os.Exit(1)
+	}
+	rootFolder := args[0]
+
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	metricsIndex := flags.String("index", "enron_people_metrics", "index to write the per-person metrics to")
+	only := flags.String("period", "", "only compute the named period (all, 2001, 2001-Q3)")
+	samples := flags.Int("betweenness-samples", network.DefaultOptions().BetweennessSamples, "number of source nodes sampled for betweenness, 0 for exact")
+	datasetID := flags.String("dataset", "", "analyze a registered dataset, posting to <dataset>_people_metrics unless -index is set")
+	registry := flags.String("datasets-index", datasetsIndex(), "index of the dataset registry, defaults to DATASETS_INDEX or "+dataset.Index)
+	flags.Parse(args[1:])
+
+	if *datasetID != "" {
+		if !dataset.IsValidID(*datasetID) {
+			fmt.Println("Dataset ids may only hold lowercase letters, digits, dashes and underscores.")
+			os.Exit(1)
+		}
+		indexSet := false
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "index" {
+				indexSet = true
+			}
+		})
+		if !indexSet {
+			*metricsIndex = dataset.MetricsIndex(*datasetID)
+		}
+	}
+
+	slog.Info("loading emails", "folder", rootFolder)
+	startTime := time.Now()
+	emails, err := email.LoadEmails(rootFolder)
+	if err != nil {
+		slog.Error("failed to load emails", "folder", rootFolder, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("emails loaded", "emails", len(emails), "duration", time.Since(startTime).String())
+
+	client := newZincClient()
+	opts := network.DefaultOptions()
+	opts.BetweennessSamples = *samples
+
+	for _, period := range network.Periods(emails) {
+		if *only != "" && period.Name != *only {
+			continue
+		}
+
+		startTime = time.Now()
+		metrics := network.Analyze(emails, period, opts)
+		slog.Info("period analyzed", "period", period.Name, "people", len(metrics), "duration", time.Since(startTime).String())
+
+		if err := email.IndexRecords(context.Background(), client, *metricsIndex, metrics, metricsID); err != nil {
+			slog.Error("failed to post metrics", "period", period.Name, "index", *metricsIndex, "error", err)
+			os.Exit(1)
+		}
+	}
+	slog.Info("network metrics sent", "index", *metricsIndex)
+
+	if *datasetID != "" {
+		if err := index.SetDatasetMetricsIndex(context.Background(), client, *registry, *datasetID, *metricsIndex); err != nil {
+			slog.Error("failed to register metrics", "dataset", *datasetID, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("dataset metrics registered", "dataset", *datasetID, "index", *metricsIndex)
+	}
+}
+
+// metricsID identifies the metrics of a person in a period, so that
+// analyzing the corpus again replaces them
+func metricsID(m network.PersonMetrics) string {
+	return m.Person + "|" + m.Period
+}
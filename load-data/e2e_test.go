@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DanielOsorio01/enron-email-search/back/app"
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	repo "github.com/DanielOsorio01/enron-email-search/back/repository/email"
+	"github.com/DanielOsorio01/enron-email-search/back/zinctest"
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+	"github.com/DanielOsorio01/enron-email-search/load-data/index"
+	"github.com/DanielOsorio01/enron-email-search/load-data/metrics"
+)
+
+// load runs the loader over the test maildir into the given sink
+func load(t *testing.T, sinkValue, indexName string) {
+	t.Helper()
+
+	emails, err := email.LoadEmails("testdata/maildir")
+	if err != nil {
+		t.Fatalf("failed to load emails: %v", err)
+	}
+	sink, err := openSink(sinkValue, newZincClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), indexName, email.ToModels(emails)); err != nil {
+		t.Fatalf("failed to write emails: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+}
+
+// get sends a request through the router and decodes the data of the response
+func get(t *testing.T, router http.Handler, path string, data interface{}) int {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response struct {
+		Data    json.RawMessage `json:"data"`
+		Error   string          `json:"error"`
+		Success bool            `json:"success"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GET %s: failed to decode response %q: %v", path, recorder.Body.String(), err)
+	}
+	if recorder.Code == http.StatusOK && data != nil {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatalf("GET %s: failed to decode data: %v", path, err)
+		}
+	}
+	return recorder.Code
+}
+
+type searchResult struct {
+	Emails []models.Email `json:"emails"`
+	Total  int            `json:"total"`
+}
+
+// expectTotal checks the number of emails a search finds
+func expectTotal(t *testing.T, router http.Handler, path string, total int) {
+	t.Helper()
+
+	var result searchResult
+	if status := get(t, router, path, &result); status != http.StatusOK {
+		t.Fatalf("GET %s: status %d", path, status)
+	}
+	if result.Total != total {
+		t.Errorf("GET %s: expected %d emails, got %d", path, total, result.Total)
+	}
+}
+
+func TestLoadIntoZincAndSearch(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	// The loader and the backend read their connection from the environment
+	t.Setenv("ZINCSEARCH_URL", server.URL)
+	t.Setenv("DB_HOST", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", server.Password)
+	t.Setenv("SEARCH_BACKEND", app.BackendZinc)
+
+	if err := server.Client().CreateIndex(context.Background(), index.EmailsDefinition("enron_emails")); err != nil {
+		t.Fatal(err)
+	}
+	load(t, sinkZinc, "enron_emails")
+	if n := server.Count("enron_emails"); n != 4 {
+		t.Fatalf("expected 4 emails in zinc, got %d", n)
+	}
+
+	emails := &repo.ZincsearchRepo{Client: server.Client()}
+	if err := emails.CheckMapping(context.Background(), "enron_emails"); err != nil {
+		t.Errorf("mapping of the created index was rejected: %v", err)
+	}
+
+	router := newRouter(t)
+
+	expectTotal(t, router, "/emails/?term=citibank", 2)
+	expectTotal(t, router, "/emails/?term=prepay+deal&search_type=matchphrase", 1)
+	expectTotal(t, router, "/emails/?term=power&start=2001-05-15", 1)
+
+	var found models.Email
+	path := "/emails/" + url.PathEscape("<1002.JavaMail.evans@thyme>")
+	if status := get(t, router, path, &found); status != http.StatusOK || found.Subject != "Board meeting" {
+		t.Errorf("GET %s: expected the board meeting email, got status %d and %+v", path, status, found)
+	}
+
+	var facets map[string][]models.FacetCount
+	get(t, router, "/emails/facets?fields=from", &facets)
+	if from := facets["from"]; len(from) == 0 || from[0].Value != "jeff.skilling@enron.com" || from[0].Count != 3 {
+		t.Errorf("expected jeff.skilling@enron.com to send 3 emails, got %+v", from)
+	}
+
+	var timeline models.Timeline
+	get(t, router, "/emails/timeline?interval=month", &timeline)
+	if timeline.Total != 4 {
+		t.Errorf("expected a timeline of 4 emails, got %d", timeline.Total)
+	}
+
+	var profile models.PersonProfile
+	get(t, router, "/people/jeff.skilling@enron.com/profile", &profile)
+	if profile.SentTotal != 3 {
+		t.Errorf("expected 3 sent emails in the profile, got %d", profile.SentTotal)
+	}
+
+	var datasets []models.Dataset
+	get(t, router, "/datasets/", &datasets)
+	if len(datasets) != 1 || datasets[0].Documents != 4 {
+		t.Errorf("expected the enron dataset with 4 emails, got %+v", datasets)
+	}
+
+	var readiness models.Readiness
+	if status := get(t, router, "/readyz", &readiness); status != http.StatusOK {
+		t.Fatalf("GET /readyz: status %d", status)
+	}
+	if readiness.Index == nil || readiness.Index.Documents != 4 || readiness.Index.LastIngest == nil {
+		t.Errorf("expected 4 emails and an ingest time, got %+v", readiness.Index)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, series := range []string{
+		`enron_http_requests_total{method="GET",route="/people/{id}/profile",status="200"} 1`,
+		`enron_zinc_requests_total{operation="es_search",outcome="ok"}`,
+		`enron_cache_misses_total{cache="profiles"} 1`,
+	} {
+		if !strings.Contains(recorder.Body.String(), series) {
+			t.Errorf("expected the backend metrics to hold %s", series)
+		}
+	}
+
+	textfile := filepath.Join(t.TempDir(), "load-data.prom")
+	if err := metrics.WriteTextfile(textfile); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(textfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, series := range []string{"enron_loader_files_parsed_total", `enron_loader_batches_posted_total{outcome="ok"}`} {
+		if !strings.Contains(string(written), series) {
+			t.Errorf("expected the loader metrics to hold %s", series)
+		}
+	}
+}
+
+func TestNotReadyWithWrongPassword(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+
+	t.Setenv("DB_HOST", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", "wrong")
+	t.Setenv("SEARCH_BACKEND", app.BackendZinc)
+	router := newRouter(t)
+
+	if status := get(t, router, "/healthz", nil); status != http.StatusOK {
+		t.Errorf("expected the backend to be live, got status %d", status)
+	}
+	if status := get(t, router, "/readyz", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("expected the backend not to be ready, got status %d", status)
+	}
+}
+
+func TestLoadIntoLocalIndexAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	load(t, "local:"+dir, "enron_emails")
+	// Loading again replaces the index instead of duplicating its emails
+	load(t, "local:"+dir, "enron_emails")
+	load(t, "local:"+dir, dataset.EmailsIndex("archive"))
+
+	t.Setenv("SEARCH_BACKEND", app.BackendLocal)
+	t.Setenv("LOCAL_INDEX_PATH", dir)
+	router := newRouter(t)
+
+	expectTotal(t, router, "/emails/?term=citibank", 2)
+	expectTotal(t, router, "/emails/?term=prepay+deal&search_type=matchphrase", 1)
+	expectTotal(t, router, "/emails/?term=deriv&search_type=prefix&field=body", 1)
+	expectTotal(t, router, "/emails/?term=sherri*&search_type=wildcard&field=from", 1)
+	expectTotal(t, router, "/emails/?term=citibnak&search_type=fuzzy", 2)
+	expectTotal(t, router, "/emails/?term=x&search_type=daterange&start=2001-06-01", 2)
+
+	// Every dataset index of the directory is served without a registry
+	var datasets []models.Dataset
+	get(t, router, "/datasets/", &datasets)
+	if len(datasets) != 2 || datasets[0].ID != "archive" || datasets[0].Documents == 0 || datasets[1].ID != "enron" {
+		t.Errorf("expected the archive and enron datasets, got %+v", datasets)
+	}
+	expectTotal(t, router, "/datasets/archive/emails/?term=citibank", 2)
+
+	// Analyses built on ZincSearch aggregations are not available
+	if status := get(t, router, "/emails/timeline", nil); status != http.StatusNotImplemented {
+		t.Errorf("expected the timeline to be unsupported, got status %d", status)
+	}
+}
+
+// newRouter serves the API configured by the environment of the test
+func newRouter(t *testing.T) http.Handler {
+	t.Helper()
+	config, err := app.LoadConfig(nil)
+	if err != nil {
+		t.Fatalf("invalid configuration: %v", err)
+	}
+	a, err := app.New(*config)
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	return a.Handler()
+}
+
+func TestAnalyzeTwice(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+	t.Setenv("ZINCSEARCH_URL", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", server.Password)
+
+	args := []string{"testdata/maildir", "-index", "enron_people_metrics", "-period", "all", "-betweenness-samples", "0"}
+	analyze(args)
+	first := server.Count("enron_people_metrics")
+	if first == 0 {
+		t.Fatal("expected metrics in zinc")
+	}
+
+	// Analyzing again replaces the metrics of every person and period
+	analyze(args)
+	if n := server.Count("enron_people_metrics"); n != first {
+		t.Errorf("expected %d metrics after analyzing twice, got %d", first, n)
+	}
+}
+
+func TestDatasetPeople(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+	t.Setenv("ZINCSEARCH_URL", server.URL)
+	t.Setenv("DB_HOST", server.URL)
+	t.Setenv("DB_USER", server.User)
+	t.Setenv("DB_PASSWORD", server.Password)
+	t.Setenv("SEARCH_BACKEND", app.BackendZinc)
+	// The loader and the backend share the registry named by the environment
+	t.Setenv("DATASETS_INDEX", "test_datasets")
+
+	ctx := context.Background()
+	client := server.Client()
+	if err := client.CreateIndex(ctx, index.EmailsDefinition("archive_v1")); err != nil {
+		t.Fatal(err)
+	}
+	load(t, sinkZinc, "archive_v1")
+	if err := index.RegisterDataset(ctx, client, datasetsIndex(), models.Dataset{ID: "archive", Name: "Archive", Index: "archive_v1"}); err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter(t)
+
+	var profile models.PersonProfile
+	get(t, router, "/datasets/archive/people/jeff.skilling@enron.com/profile", &profile)
+	if profile.SentTotal != 3 {
+		t.Errorf("expected 3 sent emails in the profile of the dataset, got %d", profile.SentTotal)
+	}
+	if status := get(t, router, "/datasets/archive/people/central", nil); status != http.StatusNotFound {
+		t.Errorf("expected no metrics before the dataset is analyzed, got status %d", status)
+	}
+
+	analyze([]string{"testdata/maildir", "-dataset", "archive", "-period", "all", "-betweenness-samples", "0"})
+	if server.Count(dataset.MetricsIndex("archive")) == 0 {
+		t.Fatal("expected the metrics of the dataset in its own index")
+	}
+	if server.Count("enron_datasets") != 0 {
+		t.Error("expected nothing written to the default registry")
+	}
+
+	// The registry is cached, a fresh router sees the metrics index
+	var datasets []models.Dataset
+	get(t, newRouter(t), "/datasets/", &datasets)
+	found := false
+	for _, d := range datasets {
+		found = found || d.ID == "archive" && d.PeopleMetricsIndex == dataset.MetricsIndex("archive")
+	}
+	if !found {
+		t.Errorf("expected the metrics index of the dataset in the registry, got %+v", datasets)
+	}
+}
+
+func TestVerifyIndexKinds(t *testing.T) {
+	server := zinctest.NewServer()
+	defer server.Close()
+	ctx, client := context.Background(), server.Client()
+
+	metricsIndex := index.PeopleMetricsDefinition("enron_people_metrics_v2")
+	if err := client.CreateIndex(ctx, metricsIndex); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Add(metricsIndex.Name, models.PersonMetrics{Person: "jeff.skilling@enron.com", Period: "all"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := index.Verify(ctx, client, metricsIndex, 1); err != nil {
+		t.Errorf("expected the people metrics index to be verified, got %v", err)
+	}
+	if err := index.Verify(ctx, client, index.EmailsDefinition(metricsIndex.Name), 1); err == nil {
+		t.Error("expected the people metrics index to fail the email mapping")
+	}
+}
@@ -3,6 +3,8 @@ package email
 import (
 	"fmt"
 	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/models"
 )
 
 type Email struct {
@@ -24,6 +26,8 @@ type Email struct {
 	XOrigin                 string    `json:"x_origin,omitempty"`
 	XFileName               string    `json:"x_filename,omitempty"`
 	Body                    string    `json:"body"`
+	FromPhonetic            []string  `json:"from_phonetic,omitempty"`
+	RecipientsPhonetic      []string  `json:"recipients_phonetic,omitempty"`
 }
 
 // PrintEmail prints the content of an Email struct.
@@ -31,3 +35,13 @@ func (e Email) String() string {
 	return fmt.Sprintf("MessageID: %s\nDate: %s\nFrom: %s\nTo: %v\nCc: %v\nBcc: %v\nSubject: %s\nMimeVersion: %s\nContentType: %s\nContentTransferEncoding: %s\nXFrom: %s\nXTo: %v\nXCc: %v\nXBcc: %v\nXFolder: %s\nXOrigin: %s\nXFileName: %s\nBody: %s\n",
 		e.MessageID, e.Date, e.From, e.To, e.Cc, e.Bcc, e.Subject, e.MimeVersion, e.ContentType, e.ContentTransferEncoding, e.XFrom, e.XTo, e.XCc, e.XBcc, e.XFolder, e.XOrigin, e.XFileName, e.Body)
 }
+
+// ToModels converts emails to the model shared with the backend, which has
+// the same fields
+func ToModels(emails []Email) []models.Email {
+	converted := make([]models.Email, len(emails))
+	for i, e := range emails {
+		converted[i] = models.Email(e)
+	}
+	return converted
+}
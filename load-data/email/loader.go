@@ -2,9 +2,12 @@ package email
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/DanielOsorio01/enron-email-search/load-data/metrics"
 )
 
 // IndexEmails processes all files in the directory tree using Depth-First Search.
@@ -20,9 +23,11 @@ func LoadEmails(root string) ([]Email, error) {
 		// Parse the email file
 		email, err := ParseEmail(path)
 		if err != nil {
-			fmt.Printf("Warning: Failed to parse file %s: %v\n", path, err)
+			metrics.FilesFailed.Inc()
+			slog.Warn("failed to parse file", "path", path, "error", err)
 			return // Continue processing other files
 		}
+		metrics.FilesParsed.Inc()
 
 		mu.Lock()
 		emails = append(emails, email)
@@ -40,6 +45,7 @@ func LoadEmails(root string) ([]Email, error) {
 		}
 
 		// Process the file in a separate goroutine
+		metrics.FilesWalked.Inc()
 		wg.Add(1)
 		go thread(path)
 		return nil
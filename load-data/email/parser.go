@@ -3,7 +3,6 @@ package email
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"time"
@@ -51,8 +50,7 @@ func ParseEmail(filePath string) (Email, error) {
 					if email.Date.IsZero() {
 						parsedDate, err := time.Parse("Mon, 2 Jan 2006 15:04:05 -0700 (MST)", value)
 						if err != nil {
-							log.Fatalf("Error parsing date: %v", err)
-							log.Fatalf("File: %s", filePath)
+							return Email{}, fmt.Errorf("failed to parse date of %s: %v", filePath, err)
 						}
 						email.Date = parsedDate
 					}
@@ -62,15 +60,15 @@ func ParseEmail(filePath string) (Email, error) {
 					}
 				case "to":
 					if len(email.To) == 0 {
-						email.To = strings.Split(value, ",")
+						email.To = splitAddresses(value)
 					}
 				case "cc":
 					if len(email.Cc) == 0 {
-						email.Cc = strings.Split(value, ",")
+						email.Cc = splitAddresses(value)
 					}
 				case "bcc":
 					if len(email.Bcc) == 0 {
-						email.Bcc = strings.Split(value, ",")
+						email.Bcc = splitAddresses(value)
 					}
 				case "subject":
 					if email.Subject == "" {
@@ -129,5 +127,18 @@ func ParseEmail(filePath string) (Email, error) {
 	}
 
 	email.Body = strings.Join(bodyLines, "\n")
+	email.AddPhoneticCodes()
 	return email, nil
 }
+
+// splitAddresses splits a comma separated address header into trimmed
+// addresses so they can be stored as exact keyword values
+func splitAddresses(value string) []string {
+	var addresses []string
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
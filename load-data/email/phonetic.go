@@ -0,0 +1,33 @@
+package email
+
+import "github.com/DanielOsorio01/enron-email-search/back/phonetic"
+
+// PhoneticCodes returns the Double Metaphone codes, primary and alternate,
+// of every word of the given names
+func PhoneticCodes(names ...string) []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, name := range names {
+		for _, word := range phonetic.NameWords(name) {
+			for _, code := range phonetic.Codes(word) {
+				if code != "" && !seen[code] {
+					seen[code] = true
+					codes = append(codes, code)
+				}
+			}
+		}
+	}
+	return codes
+}
+
+// AddPhoneticCodes fills the phonetic fields of the email from its sender
+// and recipient names and addresses
+func (e *Email) AddPhoneticCodes() {
+	e.FromPhonetic = PhoneticCodes(e.From, e.XFrom)
+
+	var recipients []string
+	for _, list := range [][]string{e.To, e.Cc, e.Bcc, e.XTo, e.XCc, e.XBcc} {
+		recipients = append(recipients, list...)
+	}
+	e.RecipientsPhonetic = PhoneticCodes(recipients...)
+}
@@ -1,98 +1,106 @@
 package email
 
 import (
-	"bytes"
-	"encoding/json"
-	"log"
-	"net/http"
-	"os"
+	"context"
+	"fmt"
+	"log/slog"
 	"sync"
-)
-
-// BulkV2Data structure for the bulkv2 API request
-type BulkV2Data struct {
-	Index   string  `json:"index"`
-	Records []Email `json:"records"`
-}
 
-func PostEmails(emails []Email) error {
-	var wg sync.WaitGroup
-	concurrencyLimit := 2
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+	"github.com/DanielOsorio01/enron-email-search/load-data/metrics"
+)
 
-	sem := make(chan struct{}, concurrencyLimit)
+// Records are posted in batches, a couple of batches at a time
+const (
+	batchSize        = 50
+	concurrencyLimit = 2
+)
 
-	// Send multiple requests in parallel
-	thread := func(index string, records []Email) {
-		defer wg.Done()
+// PostEmails sends the emails to the given index through the bulkv2 API
+func PostEmails(ctx context.Context, client *db.ZincClient, index string, emails []Email) error {
+	return PostRecords(ctx, client, index, emails)
+}
 
-		// Create a buffer to temporarily store the JSON payload
-		var buf bytes.Buffer
-		data := BulkV2Data{
-			Index:   index,
-			Records: records,
-		}
+// ZincSink writes emails to ZincSearch through the bulkv2 API
+type ZincSink struct {
+	Client *db.ZincClient
+}
 
-		// Directly encode JSON without GZIP compression
-		enc := json.NewEncoder(&buf)
-		if err := enc.Encode(data); err != nil {
-			log.Printf("Error encoding JSON: %v", err)
-			return
-		}
+// Write sends the emails to the given index
+func (s ZincSink) Write(ctx context.Context, index string, emails []models.Email) error {
+	return PostRecords(ctx, s.Client, index, emails)
+}
 
-		// Create a POST request with the plain JSON data
-		url := "http://localhost:4080"
-		if envURL := os.Getenv("ZINCSEARCH_URL"); envURL != "" {
-			url = envURL
-		}
-		endpoint := url + "/api/_bulkv2"
-		req, err := http.NewRequest("POST", endpoint, &buf)
-		if err != nil {
-			log.Printf("Error creating request: %v", err)
-			return
-		}
-		username := os.Getenv("DB_USER")
-		if username == "" {
-			username = "admin"
-		}
-		password := os.Getenv("DB_PASSWORD")
-		if password == "" {
-			password = "Complexpass#123"
-		}
-		req.SetBasicAuth(username, password)
-		req.Header.Set("Content-Type", "application/json")
+// Close does nothing, every batch is posted by Write
+func (ZincSink) Close() error {
+	return nil
+}
 
-		// Acquire a semaphore slot before making the request
-		sem <- struct{}{}
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			log.Printf("Error sending request to %s: %v", endpoint, err)
-			<-sem // Release the semaphore slot
-			return
-		}
-		defer resp.Body.Close()
+// PostRecords sends records of any type to the given index through the
+// bulkv2 API. A failed batch does not stop the others, the error reports
+// how many failed.
+func PostRecords[T any](ctx context.Context, client *db.ZincClient, index string, records []T) error {
+	return postBatches(ctx, index, records, func(batch []T) (int, error) {
+		return client.BulkV2(ctx, index, batch)
+	})
+}
 
-		// Print the response status only on error
-		if resp.StatusCode != http.StatusOK {
-			log.Printf("Request failed with status: %s", resp.Status)
+// IndexRecords sends records of any type to the given index through the
+// bulk API under the id returned by id, so that posting them again replaces
+// them instead of adding duplicates
+func IndexRecords[T any](ctx context.Context, client *db.ZincClient, index string, records []T, id func(T) string) error {
+	return postBatches(ctx, index, records, func(batch []T) (int, error) {
+		operations := make([]db.BulkOperation, 0, len(batch))
+		for _, record := range batch {
+			operations = append(operations, db.BulkOperation{Action: db.BulkIndex, ID: id(record), Doc: record})
 		}
+		return client.Bulk(ctx, index, operations)
+	})
+}
 
-		// Release the semaphore slot after the request is completed
-		<-sem
+// postBatches posts the records in batches with post, a couple of batches
+// at a time
+func postBatches[T any](ctx context.Context, index string, records []T, post func([]T) (int, error)) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
 
-	// Split the emails into chunks and process in parallel
-	chunkSize := 50 // Try smaller chunks
-	for i := 0; i < len(emails); i += chunkSize {
-		end := i + chunkSize
-		if end > len(emails) {
-			end = len(emails)
-		}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrencyLimit)
+	batches, failed := 0, 0
+	var firstErr error
+
+	// Split the records into batches and post them in parallel
+	for i := 0; i < len(records); i += batchSize {
+		end := min(i+batchSize, len(records))
+		batches++
 		wg.Add(1)
-		go thread("enron_emails", emails[i:end])
+		go func(batch []T) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			n, err := post(batch)
+			metrics.RecordsPosted.Add(float64(n))
+			if err != nil {
+				slog.Error("failed to post batch", "index", index, "records", len(batch), "error", err)
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(records[i:end])
 	}
 
 	// Wait for all goroutines to finish
 	wg.Wait()
 
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batches failed: %w", failed, batches, firstErr)
+	}
 	return nil
 }
@@ -0,0 +1,80 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aliases "github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+)
+
+// GetAlias returns the pointer document of alias stored in aliasesIndex, or
+// nil if it does not exist. ZincSearch has no index aliases, so the backend
+// resolves an alias such as enron_emails to the versioned index it points
+// to by reading this document.
+func GetAlias(ctx context.Context, client *db.ZincClient, aliasesIndex, alias string) (*aliases.Alias, error) {
+	var a aliases.Alias
+	if err := client.GetDocument(ctx, aliasesIndex, alias, &a); err != nil {
+		if db.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Verify checks that the index of def is ready to be served: it must exist,
+// hold at least minDocs documents and use the mapping of def
+func Verify(ctx context.Context, client *db.ZincClient, def Definition, minDocs int) error {
+	name := def.Name
+	info, err := client.GetIndex(ctx, name)
+	if err != nil {
+		return err
+	}
+	if info.Stats.DocNum < minDocs {
+		return fmt.Errorf("index %s holds %d documents, expected at least %d", name, info.Stats.DocNum, minDocs)
+	}
+
+	for field, want := range def.Mappings.Properties {
+		got, ok := info.Mappings.Properties[field]
+		if !ok {
+			return fmt.Errorf("index %s has no mapping for %s", name, field)
+		}
+		if got.Type != want.Type {
+			return fmt.Errorf("index %s maps %s as %s instead of %s", name, field, got.Type, want.Type)
+		}
+	}
+	return nil
+}
+
+// Switch points alias to index in a single document write to aliasesIndex,
+// remembering the index it pointed to before so that the switch can be
+// rolled back
+func Switch(ctx context.Context, client *db.ZincClient, aliasesIndex, alias, index string) (*aliases.Alias, error) {
+	current, err := GetAlias(ctx, client, aliasesIndex, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &aliases.Alias{Alias: alias, Index: index, UpdatedAt: time.Now().UTC()}
+	if current != nil && current.Index != index {
+		next.Previous = current.Index
+	}
+	if err := client.PutDocument(ctx, aliasesIndex, alias, next); err != nil {
+		return nil, fmt.Errorf("failed to switch alias %s: %w", alias, err)
+	}
+	return next, nil
+}
+
+// Rollback points alias back to the index it pointed to before the last switch
+func Rollback(ctx context.Context, client *db.ZincClient, aliasesIndex, alias string) (*aliases.Alias, error) {
+	current, err := GetAlias(ctx, client, aliasesIndex, alias)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Previous == "" {
+		return nil, fmt.Errorf("alias %s has no previous index to roll back to", alias)
+	}
+	return Switch(ctx, client, aliasesIndex, alias, current.Previous)
+}
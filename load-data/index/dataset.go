@@ -0,0 +1,59 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
+)
+
+// RegisterDataset creates or updates the document of a dataset in the
+// registry index the backend lists the datasets it can search from,
+// keeping the creation time and the metrics index of an existing one
+func RegisterDataset(ctx context.Context, client *db.ZincClient, registry string, d models.Dataset) error {
+	if !dataset.IsValidID(d.ID) {
+		return fmt.Errorf("invalid dataset id %q", d.ID)
+	}
+
+	var current models.Dataset
+	err := client.GetDocument(ctx, registry, d.ID, &current)
+	if err != nil && !db.IsNotFound(err) {
+		return fmt.Errorf("failed to read dataset %s: %w", d.ID, err)
+	}
+	if err == nil && current.CreatedAt != nil {
+		d.CreatedAt = current.CreatedAt
+	} else {
+		now := time.Now().UTC()
+		d.CreatedAt = &now
+	}
+	if d.PeopleMetricsIndex == "" {
+		d.PeopleMetricsIndex = current.PeopleMetricsIndex
+	}
+
+	if err := client.PutDocument(ctx, registry, d.ID, d); err != nil {
+		return fmt.Errorf("failed to register dataset %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+// SetDatasetMetricsIndex records in the document of a dataset in the
+// registry index the index holding its network metrics. The dataset must be
+// registered.
+func SetDatasetMetricsIndex(ctx context.Context, client *db.ZincClient, registry, id, metricsIndex string) error {
+	var d models.Dataset
+	if err := client.GetDocument(ctx, registry, id, &d); err != nil {
+		if db.IsNotFound(err) {
+			return fmt.Errorf("dataset %s is not registered, load its emails first", id)
+		}
+		return fmt.Errorf("failed to read dataset %s: %w", id, err)
+	}
+
+	d.PeopleMetricsIndex = metricsIndex
+	if err := client.PutDocument(ctx, registry, id, d); err != nil {
+		return fmt.Errorf("failed to register metrics of dataset %s: %w", id, err)
+	}
+	return nil
+}
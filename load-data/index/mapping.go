@@ -0,0 +1,97 @@
+package index
+
+import "github.com/DanielOsorio01/enron-email-search/back/db"
+
+// MappingVersion is incremented every time the mapping below changes in a
+// way that requires the emails to be reindexed
+const MappingVersion = 1
+
+// EmailAnalyzer is the analyzer applied to the subject and body. It
+// lower-cases, removes English stop words and stems with Porter's algorithm
+// so that "hedging" also finds "hedge".
+const EmailAnalyzer = "email_english"
+
+// The index types are shared with the backend through its database client
+type (
+	Property   = db.Property
+	Mappings   = db.Mappings
+	Analyzer   = db.Analyzer
+	Definition = db.IndexDefinition
+)
+
+func keyword() Property {
+	return Property{Type: "keyword", Index: true, Sortable: true, Aggregatable: true}
+}
+
+func text(analyzer string) Property {
+	return Property{Type: "text", Index: true, Store: true, Highlightable: true, Analyzer: analyzer}
+}
+
+func numeric() Property {
+	return Property{Type: "numeric", Index: true, Sortable: true, Aggregatable: true}
+}
+
+func date() Property {
+	return Property{Type: "date", Index: true, Sortable: true, Aggregatable: true, Format: "2006-01-02T15:04:05Z07:00"}
+}
+
+// EmailsDefinition returns the definition of the emails index
+func EmailsDefinition(name string) Definition {
+	def := Definition{
+		Name:        name,
+		StorageType: "disk",
+		ShardNum:    1,
+		Mappings: Mappings{Properties: map[string]Property{
+			"message_id":                keyword(),
+			"date":                      date(),
+			"from":                      keyword(),
+			"to":                        keyword(),
+			"cc":                        keyword(),
+			"bcc":                       keyword(),
+			"subject":                   text(EmailAnalyzer),
+			"mime_version":              keyword(),
+			"content_type":              keyword(),
+			"content_transfer_encoding": keyword(),
+			"x_from":                    text("standard"),
+			"x_to":                      text("standard"),
+			"x_cc":                      text("standard"),
+			"x_bcc":                     text("standard"),
+			"x_folder":                  keyword(),
+			"x_origin":                  keyword(),
+			"x_filename":                keyword(),
+			"body":                      text(EmailAnalyzer),
+			"from_phonetic":             keyword(),
+			"recipients_phonetic":       keyword(),
+		}},
+	}
+	def.Settings.Analysis.Analyzer = map[string]Analyzer{
+		EmailAnalyzer: {
+			Tokenizer:   "standard",
+			TokenFilter: []string{"lowercase", "stop", "porter"},
+		},
+	}
+	return def
+}
+
+// PeopleMetricsDefinition returns the definition of the index written by
+// the analyze command
+func PeopleMetricsDefinition(name string) Definition {
+	return Definition{
+		Name:        name,
+		StorageType: "disk",
+		ShardNum:    1,
+		Mappings: Mappings{Properties: map[string]Property{
+			"person":            keyword(),
+			"period":            keyword(),
+			"period_start":      date(),
+			"period_end":        date(),
+			"pagerank":          numeric(),
+			"betweenness":       numeric(),
+			"in_degree":         numeric(),
+			"out_degree":        numeric(),
+			"degree_centrality": numeric(),
+			"community":         numeric(),
+			"community_size":    numeric(),
+		}},
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/load-data/index"
+)
+
+const indexUsage = `Usage: load-data index <command> [flags]
+
+Commands:
+  create    create an index with the explicit mapping, before ingest
+  verify    check that an index is complete and uses the current mapping
+  switch    verify an index and point an alias to it
+  rollback  point an alias back to its previous index
+
+A zero-downtime reindex creates a versioned index, ingests into it with
+-index and switches the alias the backend reads from:
+
+  load-data index create -name enron_emails_v7
+  load-data <folder> -index enron_emails_v7
+  load-data index switch -alias enron_emails -to enron_emails_v7`
+
+// indexCommand manages the ZincSearch indexes and the aliases the backend
+// resolves index names through
+func indexCommand(args []string) {
+	if len(args) < 1 {
+		fmt.Println(indexUsage)
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "create":
+		err = createIndex(args[1:])
+	case "verify":
+		err = verifyIndex(args[1:])
+	case "switch":
+		err = switchAlias(args[1:])
+	case "rollback":
+		err = rollbackAlias(args[1:])
+	default:
+		fmt.Println(indexUsage)
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("index command failed", "command", args[0], "error", err)
+		os.Exit(1)
+	}
+}
+
+const kindUsage = "kind of documents the index holds (emails or people-metrics)"
+
+const aliasesUsage = "index of the alias pointers, defaults to ALIASES_INDEX or " + alias.Index
+
+// definition returns the definition of an index named name holding
+// documents of the given kind
+func definition(kind, name string) (index.Definition, error) {
+	switch kind {
+	case "emails":
+		return index.EmailsDefinition(name), nil
+	case "people-metrics":
+		return index.PeopleMetricsDefinition(name), nil
+	}
+	return index.Definition{}, fmt.Errorf("unknown index kind %q", kind)
+}
+
+// createIndex creates an index with the explicit mapping defined in the
+// index package
+func createIndex(args []string) error {
+	flags := flag.NewFlagSet("index create", flag.ExitOnError)
+	name := flags.String("name", "enron_emails", "name of the index to create")
+	kind := flags.String("kind", "emails", kindUsage)
+	force := flags.Bool("force", false, "delete the index first if it already exists")
+	flags.Parse(args)
+
+	def, err := definition(*kind, *name)
+	if err != nil {
+		return err
+	}
+
+	ctx, client := context.Background(), newZincClient()
+	exists, err := client.IndexExists(ctx, *name)
+	if err != nil {
+		return fmt.Errorf("failed to check index %s: %w", *name, err)
+	}
+	if exists {
+		if !*force {
+			return fmt.Errorf("index %s already exists, use -force to recreate it", *name)
+		}
+		if err := client.DeleteIndex(ctx, *name); err != nil {
+			return err
+		}
+		slog.Info("index deleted", "index", *name)
+	}
+
+	if err := client.CreateIndex(ctx, def); err != nil {
+		return err
+	}
+	slog.Info("index created", "index", *name, "mapping_version", index.MappingVersion)
+	return nil
+}
+
+// verifyIndex checks that an index is ready to be switched to
+func verifyIndex(args []string) error {
+	flags := flag.NewFlagSet("index verify", flag.ExitOnError)
+	name := flags.String("name", "", "name of the index to verify")
+	kind := flags.String("kind", "emails", kindUsage)
+	minDocs := flags.Int("min-docs", 1, "minimum number of documents the index must hold")
+	flags.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	def, err := definition(*kind, *name)
+	if err != nil {
+		return err
+	}
+	if err := index.Verify(context.Background(), newZincClient(), def, *minDocs); err != nil {
+		return err
+	}
+	slog.Info("index is ready", "index", *name)
+	return nil
+}
+
+// switchAlias verifies an index and points an alias to it
+func switchAlias(args []string) error {
+	flags := flag.NewFlagSet("index switch", flag.ExitOnError)
+	alias := flags.String("alias", "enron_emails", "alias the backend reads from")
+	aliases := flags.String("aliases-index", aliasesIndex(), aliasesUsage)
+	to := flags.String("to", "", "index to point the alias to")
+	kind := flags.String("kind", "emails", kindUsage)
+	minDocs := flags.Int("min-docs", 1, "minimum number of documents the index must hold")
+	flags.Parse(args)
+
+	if *to == "" {
+		return fmt.Errorf("-to is required")
+	}
+	def, err := definition(*kind, *to)
+	if err != nil {
+		return err
+	}
+	ctx, client := context.Background(), newZincClient()
+	if err := index.Verify(ctx, client, def, *minDocs); err != nil {
+		return fmt.Errorf("refusing to switch: %w", err)
+	}
+
+	a, err := index.Switch(ctx, client, *aliases, *alias, *to)
+	if err != nil {
+		return err
+	}
+	slog.Info("alias switched", "alias", a.Alias, "index", a.Index, "previous", a.Previous)
+	return nil
+}
+
+// rollbackAlias points an alias back to the index it pointed to before
+func rollbackAlias(args []string) error {
+	flags := flag.NewFlagSet("index rollback", flag.ExitOnError)
+	alias := flags.String("alias", "enron_emails", "alias the backend reads from")
+	aliases := flags.String("aliases-index", aliasesIndex(), aliasesUsage)
+	flags.Parse(args)
+
+	a, err := index.Rollback(context.Background(), newZincClient(), *aliases, *alias)
+	if err != nil {
+		return err
+	}
+	slog.Info("alias rolled back", "alias", a.Alias, "index", a.Index)
+	return nil
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	_ "net/http/pprof"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"time"
 
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/logging"
+	"github.com/DanielOsorio01/enron-email-search/back/models"
 	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+	"github.com/DanielOsorio01/enron-email-search/load-data/index"
+	"github.com/DanielOsorio01/enron-email-search/load-data/metrics"
+	"github.com/DanielOsorio01/enron-email-search/load-data/stats"
 )
 
+// metricsInterval is how often the -metrics-file is rewritten during an ingest
+const metricsInterval = 10 * time.Second
+
 func main() {
+	setupLogging()
 
 	// Check if the user provided at least one argument (the folder name)
 	if len(os.Args) < 2 {
@@ -21,64 +32,220 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Subcommands run instead of indexing emails
+	switch os.Args[1] {
+	case "analyze":
+		analyze(os.Args[2:])
+		return
+	case "index":
+		indexCommand(os.Args[2:])
+		return
+	}
+
 	// read the folder name from the first argument
 	rootFolder := os.Args[1]
 
 	var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 	var memprofile = flag.String("memprofile", "", "write memory profile to file")
+	var termStats = flag.String("term-stats", "", "write corpus term statistics to file")
+	var dictionary = flag.String("dictionary", "", "write spelling dictionary to file")
+	var people = flag.String("people", "", "write people autocomplete index to file")
+	var indexName = flag.String("index", "enron_emails", "index to post the emails to, such as a versioned enron_emails_v7")
+	var datasetID = flag.String("dataset", "", "register the emails as this dataset, posting them to <dataset>_emails unless -index is set")
+	var datasetName = flag.String("dataset-name", "", "display name of the dataset, defaults to its id")
+	var registry = flag.String("datasets-index", datasetsIndex(), "index of the dataset registry, defaults to DATASETS_INDEX or "+dataset.Index)
+	var sinkFlag = flag.String("sink", sinkZinc, "where to write the emails: zinc, or local:/path for a local index")
+	var metricsAddr = flag.String("metrics-addr", "", "serve ingest metrics at /metrics on this address, such as :9101")
+	var metricsFile = flag.String("metrics-file", "", "write ingest metrics to this file for the node exporter textfile collector")
+	var logLevel = flag.String("log-level", "", "lowest level logged: debug, info, warn or error, defaults to LOG_LEVEL or info")
 
 	flag.CommandLine.Parse(os.Args[2:]) // Parse only the flags
 
-	// Debugging: Print parsed values
-	fmt.Println("Root folder:", rootFolder)
-	fmt.Println("cpuprofile flag value:", *cpuprofile)
-	fmt.Println("memprofile flag value:", *memprofile)
+	if *logLevel != "" {
+		level, err := logging.ParseLevel(*logLevel)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		logging.SetLevel(level)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Every Zinc request of the run carries the same ID, so that the Zinc
+	// logs of a run can be told apart
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	if *metricsAddr != "" {
+		if err := metrics.Serve(*metricsAddr); err != nil {
+			slog.Error("failed to serve metrics", "addr", *metricsAddr, "error", err)
+			os.Exit(1)
+		}
+	}
+	if *metricsFile != "" {
+		go metrics.WriteTextfileEvery(ctx, *metricsFile, metricsInterval)
+		defer func() {
+			if err := metrics.WriteTextfile(*metricsFile); err != nil {
+				slog.Error("failed to write metrics", "path", *metricsFile, "error", err)
+			}
+		}()
+	}
+
+	client := newZincClient()
+	sink, err := openSink(*sinkFlag, client)
+	if err != nil {
+		slog.Error("failed to open sink", "sink", *sinkFlag, "error", err)
+		os.Exit(1)
+	}
+	toZinc := *sinkFlag == sinkZinc
+
+	if *datasetID != "" {
+		if !dataset.IsValidID(*datasetID) {
+			fmt.Println("Dataset ids may only hold lowercase letters, digits, dashes and underscores.")
+			os.Exit(1)
+		}
+		indexSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "index" {
+				indexSet = true
+			}
+		})
+		if !indexSet {
+			*indexName = dataset.EmailsIndex(*datasetID)
+		}
+	}
+
+	slog.Debug("flags parsed", "folder", rootFolder, "cpuprofile", *cpuprofile, "memprofile", *memprofile)
 
 	if *cpuprofile != "" {
-		fmt.Println("Starting CPU profiling...")
+		slog.Info("starting CPU profiling", "path", *cpuprofile)
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
-			log.Fatal(err)
+			slog.Error("failed to create CPU profile", "path", *cpuprofile, "error", err)
+			os.Exit(1)
 		}
 		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
 	}
 	// Start memory profiling if the flag is set
 	if *memprofile != "" {
-		fmt.Println("Starting memory profiling...")
+		slog.Info("starting memory profiling", "path", *memprofile)
 		f, err := os.Create(*memprofile)
 		if err != nil {
-			log.Fatal(err)
+			slog.Error("failed to create memory profile", "path", *memprofile, "error", err)
+			os.Exit(1)
 		}
 		defer func() {
 			runtime.GC() // Force garbage collection to capture all data
 			if err := pprof.WriteHeapProfile(f); err != nil {
-				log.Fatal("could not write memory profile:", err)
+				slog.Error("failed to write memory profile", "path", *memprofile, "error", err)
+				os.Exit(1)
 			}
 			f.Close()
-			fmt.Println("Memory profiling complete.")
+			slog.Info("memory profiling complete", "path", *memprofile)
 		}()
 	}
 
 	// Read email files
-	fmt.Printf("Indexing emails in %s...\n", rootFolder)
+	slog.Info("indexing emails", "folder", rootFolder)
 	startTime := time.Now()
 	emails, err := email.LoadEmails(rootFolder)
 	duration := time.Since(startTime)
 	if err != nil {
-		fmt.Printf("Error indexing emails: %v\n", err)
+		slog.Error("failed to index emails", "folder", rootFolder, "error", err)
 		return
 	}
-	fmt.Printf("%d emails indexed in %v.\n", len(emails), duration)
+	slog.Info("emails indexed", "emails", len(emails), "duration", duration.String())
 
-	fmt.Println("Posting emails to the database...")
+	if *termStats != "" || *dictionary != "" {
+		slog.Info("computing term statistics")
+		startTime = time.Now()
+		frequencies := stats.DocumentFrequencies(emails, 2)
+		if *termStats != "" {
+			if err := frequencies.Write(*termStats); err != nil {
+				slog.Error("failed to write term statistics", "path", *termStats, "error", err)
+				return
+			}
+			slog.Info("term statistics written", "path", *termStats, "duration", time.Since(startTime).String())
+		}
+		if *dictionary != "" {
+			if err := stats.BuildDictionary(emails, frequencies, 5).Write(*dictionary); err != nil {
+				slog.Error("failed to write dictionary", "path", *dictionary, "error", err)
+				return
+			}
+			slog.Info("spelling dictionary written", "path", *dictionary, "duration", time.Since(startTime).String())
+		}
+	}
+
+	if *people != "" {
+		slog.Info("collecting people")
+		startTime = time.Now()
+		if err := stats.BuildPeople(emails).Write(*people); err != nil {
+			slog.Error("failed to write people", "path", *people, "error", err)
+			return
+		}
+		slog.Info("people written", "path", *people, "duration", time.Since(startTime).String())
+	}
+
+	// A new dataset gets an index with the email mapping before posting,
+	// otherwise Zinc would infer the mapping from the first batch
+	if *datasetID != "" && toZinc {
+		exists, err := client.IndexExists(ctx, *indexName)
+		if err != nil {
+			slog.Error("failed to check index", "index", *indexName, "error", err)
+			return
+		}
+		if !exists {
+			if err := client.CreateIndex(ctx, index.EmailsDefinition(*indexName)); err != nil {
+				slog.Error("failed to create index", "index", *indexName, "error", err)
+				return
+			}
+		}
+	}
+
+	slog.Info("posting emails", "sink", *sinkFlag, "index", *indexName)
 	startTime = time.Now()
-	err = email.PostEmails(emails)
+	err = sink.Write(ctx, *indexName, email.ToModels(emails))
+	if err == nil {
+		err = sink.Close()
+	}
 	duration = time.Since(startTime)
 	if err != nil {
-		fmt.Printf("Error sending emails to bulkv2 API: %v\n", err)
+		slog.Error("failed to write emails", "sink", *sinkFlag, "index", *indexName, "error", err)
 		return
 	}
-	fmt.Printf("Emails sent to Zincsearch database in %v.\n", duration)
+	slog.Info("emails sent", "index", *indexName, "duration", duration.String())
+
+	// Register the dataset once its emails are searchable. The local backend
+	// serves every index of its directory without a registry.
+	if *datasetID != "" && toZinc {
+		name := *datasetName
+		if name == "" {
+			name = *datasetID
+		}
+		err = index.RegisterDataset(ctx, client, *registry, models.Dataset{
+			ID:    *datasetID,
+			Name:  name,
+			Index: *indexName,
+		})
+		if err != nil {
+			slog.Error("failed to register dataset", "dataset", *datasetID, "error", err)
+			return
+		}
+		slog.Info("dataset registered", "dataset", *datasetID)
+	}
+
+}
 
+// setupLogging logs as JSON to stdout at the LOG_LEVEL level, info by
+// default. The -log-level flag of an ingest overrides it.
+func setupLogging() {
+	logging.Setup(os.Stdout, slog.LevelInfo)
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		level, err := logging.ParseLevel(value)
+		if err != nil {
+			slog.Warn("invalid setting, using the default", "key", "LOG_LEVEL", "value", value, "default", "INFO")
+			return
+		}
+		logging.SetLevel(level)
+	}
 }
@@ -0,0 +1,119 @@
+// Package metrics collects the Prometheus metrics of an ingest, so that a
+// long run can be watched while it goes. They are served over HTTP or
+// written to a file for the node exporter textfile collector.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+)
+
+const namespace = "enron_loader"
+
+// Counters of the ingest, updated by the email package and ObserveZinc
+var (
+	FilesWalked = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "files_walked_total",
+		Help:      "Files found in the maildir.",
+	})
+	FilesParsed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "files_parsed_total",
+		Help:      "Files parsed into emails.",
+	})
+	FilesFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "files_failed_total",
+		Help:      "Files that could not be parsed.",
+	})
+	RecordsPosted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_posted_total",
+		Help:      "Records ZincSearch accepted.",
+	})
+	BatchesPosted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "batches_posted_total",
+		Help:      "Bulk requests sent to ZincSearch, by outcome (ok or error).",
+	}, []string{"outcome"})
+	BytesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_sent_total",
+		Help:      "Bytes of bulk requests sent to ZincSearch, retries included.",
+	})
+)
+
+// Registry holds the loader metrics and the Go runtime ones
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		FilesWalked,
+		FilesParsed,
+		FilesFailed,
+		RecordsPosted,
+		BatchesPosted,
+		BytesSent,
+	)
+}
+
+// ObserveZinc counts the bulk requests sent to Zinc, it is meant for the
+// Observe option of the database client
+func ObserveZinc(call db.Call) {
+	if call.Operation != "bulk" && call.Operation != "bulkv2" {
+		return
+	}
+	outcome := "ok"
+	if call.Err != nil {
+		outcome = "error"
+	}
+	BatchesPosted.WithLabelValues(outcome).Inc()
+	BytesSent.Add(float64(call.BytesSent * call.Attempts))
+}
+
+// Serve exposes the metrics at /metrics on addr until the process exits
+func Serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// WriteTextfile writes the metrics to path, replacing the previous file
+// atomically so that the node exporter never reads a partial one
+func WriteTextfile(path string) error {
+	return prometheus.WriteToTextfile(path, Registry)
+}
+
+// WriteTextfileEvery writes the metrics to path at every interval until ctx
+// is done
+func WriteTextfileEvery(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := WriteTextfile(path); err != nil {
+				slog.Warn("failed to write metrics", "path", path, "error", err)
+			}
+		}
+	}
+}
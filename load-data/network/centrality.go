@@ -0,0 +1,161 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+)
+
+// PageRank computes the weighted PageRank of every node. The rank of
+// nodes without outgoing edges is spread evenly over the whole graph.
+func PageRank(g *Graph, damping float64, maxIterations int, tolerance float64) []float64 {
+	n := g.Len()
+	if n == 0 {
+		return nil
+	}
+
+	outWeight := make([]float64, n)
+	for i, targets := range g.Out {
+		for _, weight := range targets {
+			outWeight[i] += weight
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	next := make([]float64, n)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		dangling := 0.0
+		for i := range rank {
+			if outWeight[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for source, targets := range g.Out {
+			if outWeight[source] == 0 {
+				continue
+			}
+			share := damping * rank[source] / outWeight[source]
+			for target, weight := range targets {
+				next[target] += share * weight
+			}
+		}
+
+		diff := 0.0
+		for i := range rank {
+			diff += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if diff < tolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// DegreeCentrality returns the number of distinct correspondents of every
+// node in each direction, and the number of distinct correspondents in either
+// direction normalized by the maximum possible degree
+func DegreeCentrality(g *Graph) (in, out []int, centrality []float64) {
+	n := g.Len()
+	in = make([]int, n)
+	out = make([]int, n)
+	centrality = make([]float64, n)
+
+	for i, neighbors := range g.Undirected() {
+		out[i] = len(g.Out[i])
+		if n > 1 {
+			centrality[i] = float64(len(neighbors)) / float64(n-1)
+		}
+	}
+	for _, targets := range g.Out {
+		for target := range targets {
+			in[target]++
+		}
+	}
+	return in, out, centrality
+}
+
+// Betweenness computes the normalized betweenness centrality of every node
+// on the unweighted directed graph using Brandes' algorithm. When samples is
+// positive and smaller than the number of nodes, only that many randomly
+// chosen source nodes are used and the result is extrapolated, which keeps
+// the computation tractable on large graphs.
+func Betweenness(g *Graph, samples int, seed int64) []float64 {
+	n := g.Len()
+	betweenness := make([]float64, n)
+	if n < 3 {
+		return betweenness
+	}
+
+	adj := make([][]int, n)
+	for i, targets := range g.Out {
+		for target := range targets {
+			adj[i] = append(adj[i], target)
+		}
+	}
+
+	sources := rand.New(rand.NewSource(seed)).Perm(n)
+	if samples > 0 && samples < n {
+		sources = sources[:samples]
+	}
+
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+
+	for _, s := range sources {
+		for i := 0; i < n; i++ {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		sigma[s] = 1
+		dist[s] = 0
+		stack = stack[:0]
+		queue = append(queue[:0], s)
+
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				betweenness[w] += delta[w]
+			}
+		}
+	}
+
+	scale := float64(n) / float64(len(sources)) / float64((n-1)*(n-2))
+	for i := range betweenness {
+		betweenness[i] *= scale
+	}
+	return betweenness
+}
@@ -0,0 +1,109 @@
+package network
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// Graph is a weighted directed sender to recipient graph. Nodes are
+// identified by their position in Nodes, and Out[i] maps each recipient of
+// node i to the number of messages it was sent.
+type Graph struct {
+	Nodes []string
+	Out   []map[int]float64
+	index map[string]int
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{index: make(map[string]int)}
+}
+
+// BuildGraph builds the communication graph of the emails dated within
+// [start, end). Zero times leave that side of the window open.
+func BuildGraph(emails []email.Email, start, end time.Time) *Graph {
+	g := NewGraph()
+	for _, e := range emails {
+		if !start.IsZero() && e.Date.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !e.Date.Before(end) {
+			continue
+		}
+		g.AddEmail(e)
+	}
+	return g
+}
+
+// AddEmail adds an edge from the sender to every distinct recipient
+func (g *Graph) AddEmail(e email.Email) {
+	from := normalize(e.From)
+	if from == "" {
+		return
+	}
+	source := g.node(from)
+
+	seen := make(map[string]bool)
+	for _, recipients := range [][]string{e.To, e.Cc, e.Bcc} {
+		for _, recipient := range recipients {
+			recipient = normalize(recipient)
+			if recipient == "" || recipient == from || seen[recipient] {
+				continue
+			}
+			seen[recipient] = true
+			g.Out[source][g.node(recipient)]++
+		}
+	}
+}
+
+// Len returns the number of nodes in the graph
+func (g *Graph) Len() int {
+	return len(g.Nodes)
+}
+
+// In returns the reverse adjacency of the graph
+func (g *Graph) In() []map[int]float64 {
+	in := make([]map[int]float64, g.Len())
+	for i := range in {
+		in[i] = make(map[int]float64)
+	}
+	for source, targets := range g.Out {
+		for target, weight := range targets {
+			in[target][source] += weight
+		}
+	}
+	return in
+}
+
+// Undirected returns the symmetric adjacency of the graph where the weight
+// between two nodes is the sum of the weights in both directions
+func (g *Graph) Undirected() []map[int]float64 {
+	adj := make([]map[int]float64, g.Len())
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	for source, targets := range g.Out {
+		for target, weight := range targets {
+			adj[source][target] += weight
+			adj[target][source] += weight
+		}
+	}
+	return adj
+}
+
+func (g *Graph) node(id string) int {
+	i, ok := g.index[id]
+	if !ok {
+		i = len(g.Nodes)
+		g.index[id] = i
+		g.Nodes = append(g.Nodes, id)
+		g.Out = append(g.Out, make(map[int]float64))
+	}
+	return i
+}
+
+func normalize(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
@@ -0,0 +1,138 @@
+package network
+
+import "sort"
+
+// Louvain detects communities by greedily maximizing modularity on the
+// undirected version of the graph. It returns the community of every node,
+// numbered from 0 by decreasing community size.
+func Louvain(g *Graph) []int {
+	n := g.Len()
+	adj := g.Undirected()
+
+	// membership maps every original node to its node in the current level
+	membership := make([]int, n)
+	for i := range membership {
+		membership[i] = i
+	}
+
+	for {
+		communities, moved := louvainLevel(adj)
+		if !moved {
+			break
+		}
+
+		// Renumber the communities and collapse each one into a single node
+		renumber := make(map[int]int)
+		for _, c := range communities {
+			if _, ok := renumber[c]; !ok {
+				renumber[c] = len(renumber)
+			}
+		}
+		for i, node := range membership {
+			membership[i] = renumber[communities[node]]
+		}
+
+		next := make([]map[int]float64, len(renumber))
+		for i := range next {
+			next[i] = make(map[int]float64)
+		}
+		for i, neighbors := range adj {
+			ci := renumber[communities[i]]
+			for j, weight := range neighbors {
+				next[ci][renumber[communities[j]]] += weight
+			}
+		}
+		if len(next) == len(adj) {
+			break
+		}
+		adj = next
+	}
+
+	return rankCommunities(membership)
+}
+
+// louvainLevel runs the local moving phase of the algorithm on a single
+// level of the graph. Self loops in adj carry the weight inside collapsed
+// communities, counted in both directions.
+func louvainLevel(adj []map[int]float64) ([]int, bool) {
+	n := len(adj)
+	community := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n)
+	m2 := 0.0
+
+	for i, neighbors := range adj {
+		community[i] = i
+		for _, weight := range neighbors {
+			degree[i] += weight
+		}
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return community, false
+	}
+
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for i, neighbors := range adj {
+			current := community[i]
+
+			// Weight from i to each neighbouring community
+			links := make(map[int]float64)
+			for j, weight := range neighbors {
+				if j != i {
+					links[community[j]] += weight
+				}
+			}
+
+			total[current] -= degree[i]
+			best, bestGain := current, links[current]-total[current]*degree[i]/m2
+			for c, weight := range links {
+				gain := weight - total[c]*degree[i]/m2
+				// Only move on a strict improvement so the loop terminates
+				if gain > bestGain+1e-12 || (gain == bestGain && best != current && c < best) {
+					best, bestGain = c, gain
+				}
+			}
+			total[best] += degree[i]
+
+			if best != current {
+				community[i] = best
+				improved = true
+				moved = true
+			}
+		}
+	}
+	return community, moved
+}
+
+// rankCommunities renumbers communities so that 0 is the largest one
+func rankCommunities(membership []int) []int {
+	sizes := make(map[int]int)
+	for _, c := range membership {
+		sizes[c]++
+	}
+
+	order := make([]int, 0, len(sizes))
+	for c := range sizes {
+		order = append(order, c)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if sizes[order[a]] != sizes[order[b]] {
+			return sizes[order[a]] > sizes[order[b]]
+		}
+		return order[a] < order[b]
+	})
+
+	rank := make(map[int]int, len(order))
+	for i, c := range order {
+		rank[c] = i
+	}
+	result := make([]int, len(membership))
+	for i, c := range membership {
+		result[i] = rank[c]
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// PeriodAll is the name of the period spanning the whole corpus
+const PeriodAll = "all"
+
+// PersonMetrics holds the network metrics of one person within one period
+type PersonMetrics struct {
+	Person           string     `json:"person"`
+	Period           string     `json:"period"`
+	PeriodStart      *time.Time `json:"period_start,omitempty"`
+	PeriodEnd        *time.Time `json:"period_end,omitempty"`
+	PageRank         float64    `json:"pagerank"`
+	Betweenness      float64    `json:"betweenness"`
+	InDegree         int        `json:"in_degree"`
+	OutDegree        int        `json:"out_degree"`
+	DegreeCentrality float64    `json:"degree_centrality"`
+	Community        int        `json:"community"`
+	CommunitySize    int        `json:"community_size"`
+}
+
+// Period is a named time window to compute metrics for
+type Period struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Options tunes the computation of the network metrics
+type Options struct {
+	Damping            float64
+	MaxIterations      int
+	Tolerance          float64
+	BetweennessSamples int
+	Seed               int64
+}
+
+// DefaultOptions returns the default analysis options
+func DefaultOptions() Options {
+	return Options{
+		Damping:            0.85,
+		MaxIterations:      100,
+		Tolerance:          1e-6,
+		BetweennessSamples: 500,
+		Seed:               1,
+	}
+}
+
+// Periods returns the whole corpus period followed by every calendar year
+// and quarter that contains at least one email
+func Periods(emails []email.Email) []Period {
+	periods := []Period{{Name: PeriodAll}}
+
+	years := make(map[int]bool)
+	quarters := make(map[time.Time]bool)
+	for _, e := range emails {
+		if e.Date.IsZero() {
+			continue
+		}
+		d := e.Date.UTC()
+		years[d.Year()] = true
+		quarters[time.Date(d.Year(), time.Month((int(d.Month())-1)/3*3+1), 1, 0, 0, 0, 0, time.UTC)] = true
+	}
+
+	for year := range years {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		periods = append(periods, Period{Name: fmt.Sprint(year), Start: start, End: start.AddDate(1, 0, 0)})
+	}
+	for start := range quarters {
+		name := fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+		periods = append(periods, Period{Name: name, Start: start, End: start.AddDate(0, 3, 0)})
+	}
+
+	sort.Slice(periods[1:], func(i, j int) bool {
+		return periods[i+1].Name < periods[j+1].Name
+	})
+	return periods
+}
+
+// Analyze computes the metrics of every person in the communication graph
+// of the emails dated within the period
+func Analyze(emails []email.Email, period Period, opts Options) []PersonMetrics {
+	g := BuildGraph(emails, period.Start, period.End)
+
+	pagerank := PageRank(g, opts.Damping, opts.MaxIterations, opts.Tolerance)
+	betweenness := Betweenness(g, opts.BetweennessSamples, opts.Seed)
+	in, out, degree := DegreeCentrality(g)
+	communities := Louvain(g)
+
+	sizes := make(map[int]int)
+	for _, c := range communities {
+		sizes[c]++
+	}
+
+	metrics := make([]PersonMetrics, g.Len())
+	for i, person := range g.Nodes {
+		metrics[i] = PersonMetrics{
+			Person:           person,
+			Period:           period.Name,
+			PageRank:         pagerank[i],
+			Betweenness:      betweenness[i],
+			InDegree:         in[i],
+			OutDegree:        out[i],
+			DegreeCentrality: degree[i],
+			Community:        communities[i],
+			CommunitySize:    sizes[communities[i]],
+		}
+		if !period.Start.IsZero() {
+			metrics[i].PeriodStart = &period.Start
+			metrics[i].PeriodEnd = &period.End
+		}
+	}
+	return metrics
+}
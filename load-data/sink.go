@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/back/localindex"
+	"github.com/DanielOsorio01/enron-email-search/back/storage"
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// sinkZinc is the value of -sink that posts emails to ZincSearch
+const sinkZinc = "zinc"
+
+// openSink opens the storage described by the -sink flag: zinc, or
+// local:/path for a local index the backend can serve without ZincSearch
+func openSink(value string, client *db.ZincClient) (storage.Sink, error) {
+	if value == sinkZinc {
+		return email.ZincSink{Client: client}, nil
+	}
+	if dir, ok := strings.CutPrefix(value, "local:"); ok && dir != "" {
+		return localindex.Create(dir)
+	}
+	return nil, fmt.Errorf("unknown sink %q, expected zinc or local:/path", value)
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// Dictionary holds the words spelling suggestions are drawn from, with the
+// number of emails each one appears in
+type Dictionary struct {
+	Words map[string]int `json:"words"`
+}
+
+// BuildDictionary collects the frequent terms of the corpus together with
+// the words of every person name and address found in the headers. Terms
+// found in fewer than minCount emails are left out since they are often
+// misspellings themselves, but names are always kept.
+func BuildDictionary(emails []email.Email, terms *TermStats, minCount int) *Dictionary {
+	dict := &Dictionary{Words: make(map[string]int)}
+	for word, count := range terms.DocumentFrequencies {
+		if count >= minCount {
+			dict.Words[word] = count
+		}
+	}
+
+	for _, e := range emails {
+		seen := make(map[string]bool)
+		names := []string{e.XFrom, e.From}
+		for _, list := range [][]string{e.XTo, e.XCc, e.XBcc, e.To, e.Cc, e.Bcc} {
+			names = append(names, list...)
+		}
+		for _, name := range names {
+			for _, word := range nameWords(name) {
+				if !seen[word] {
+					seen[word] = true
+					dict.Words[word]++
+				}
+			}
+		}
+	}
+	return dict
+}
+
+// nameWords splits a display name or an address into words, dropping the
+// domain of addresses and the angle bracket part of X-From style names
+func nameWords(name string) []string {
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+
+	var words []string
+	for _, word := range concordance.Tokenize(strings.NewReplacer(".", " ", "_", " ").Replace(name)) {
+		if concordance.IsIndexable(word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Write saves the dictionary as JSON to path
+func (d *Dictionary) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(d); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/DanielOsorio01/enron-email-search/back/concordance"
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// TermStats holds the corpus-wide document frequency of every term
+type TermStats struct {
+	Documents           int            `json:"documents"`
+	DocumentFrequencies map[string]int `json:"document_frequencies"`
+}
+
+// DocumentFrequencies counts the number of emails each term appears in,
+// looking at both the subject and the body. Terms found in fewer than
+// minCount emails are dropped to keep the result small.
+func DocumentFrequencies(emails []email.Email, minCount int) *TermStats {
+	stats := &TermStats{
+		Documents:           len(emails),
+		DocumentFrequencies: make(map[string]int),
+	}
+
+	for _, e := range emails {
+		seen := make(map[string]bool)
+		for _, text := range []string{e.Subject, e.Body} {
+			for _, word := range concordance.Tokenize(text) {
+				if seen[word] || !concordance.IsIndexable(word) {
+					continue
+				}
+				seen[word] = true
+				stats.DocumentFrequencies[word]++
+			}
+		}
+	}
+
+	for word, count := range stats.DocumentFrequencies {
+		if count < minCount {
+			delete(stats.DocumentFrequencies, word)
+		}
+	}
+	return stats
+}
+
+// Write saves the statistics as JSON to path
+func (s *TermStats) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(s); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
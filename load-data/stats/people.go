@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+// Person is an address seen in the corpus with the names it is known by
+type Person struct {
+	Address   string `json:"address"`
+	Name      string `json:"name,omitempty"`
+	Canonical string `json:"canonical,omitempty"`
+	Messages  int    `json:"messages"`
+}
+
+// People is the list of every address of the corpus, used by the backend
+// to autocomplete people
+type People struct {
+	People []Person `json:"people"`
+}
+
+type personCounts struct {
+	messages int
+	names    map[string]int
+}
+
+// BuildPeople collects every sender and recipient address with the number
+// of messages it appears in and its most common display name. Display
+// names are taken from the X- headers when they line up with the address
+// headers.
+func BuildPeople(emails []email.Email) *People {
+	counts := make(map[string]*personCounts)
+	add := func(address, name string) {
+		address = strings.ToLower(strings.TrimSpace(address))
+		if address == "" {
+			return
+		}
+		c, ok := counts[address]
+		if !ok {
+			c = &personCounts{names: make(map[string]int)}
+			counts[address] = c
+		}
+		c.messages++
+		if name = displayName(name); name != "" {
+			c.names[name]++
+		}
+	}
+
+	for _, e := range emails {
+		add(e.From, e.XFrom)
+		for _, pair := range [][2][]string{{e.To, e.XTo}, {e.Cc, e.XCc}, {e.Bcc, e.XBcc}} {
+			addresses, names := pair[0], parseNames(pair[1])
+			for i, address := range addresses {
+				name := ""
+				if len(names) == len(addresses) {
+					name = names[i]
+				}
+				add(address, name)
+			}
+		}
+	}
+
+	people := &People{People: make([]Person, 0, len(counts))}
+	for address, c := range counts {
+		people.People = append(people.People, Person{
+			Address:   address,
+			Name:      mostCommon(c.names),
+			Canonical: canonicalName(address),
+			Messages:  c.messages,
+		})
+	}
+	sort.Slice(people.People, func(i, j int) bool {
+		return people.People[i].Address < people.People[j].Address
+	})
+	return people
+}
+
+// parseNames parses the display names of an X- recipient header, which the
+// email parser splits on every comma although names such as "Lay, Kenneth"
+// hold one. The header is parsed again as an address list: commas within
+// quotes or angle brackets do not separate names, and when the header has
+// angle bracket parts a name only ends after its own.
+func parseNames(parts []string) []string {
+	header := strings.Join(parts, ",")
+	bracketed := strings.Contains(header, "<")
+
+	var names []string
+	var name strings.Builder
+	quoted, depth, closed := false, 0, false
+	flush := func() {
+		if n := strings.TrimSpace(name.String()); n != "" {
+			names = append(names, n)
+		}
+		name.Reset()
+		closed = false
+	}
+	for _, r := range header {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '<' && !quoted:
+			depth++
+		case r == '>' && !quoted && depth > 0:
+			depth--
+			closed = depth == 0
+		case r == ',' && !quoted && depth == 0 && (!bracketed || closed):
+			flush()
+			continue
+		}
+		name.WriteRune(r)
+	}
+	flush()
+	return names
+}
+
+// displayName strips the angle bracket part of X- header names, such as the
+// Exchange path in "Allen, Phillip K. </O=ENRON/OU=NA/CN=RECIPIENTS/CN=PALLEN>"
+func displayName(name string) string {
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.Trim(strings.TrimSpace(name), `"'`)
+}
+
+// canonicalName derives a person name from the local part of an address,
+// so that jeff.skilling@enron.com becomes Jeff Skilling. Addresses whose
+// local part is not made of separated words get no canonical name.
+func canonicalName(address string) string {
+	local, _, _ := strings.Cut(address, "@")
+	words := strings.FieldsFunc(local, func(r rune) bool {
+		return r == '.' || r == '_'
+	})
+	if len(words) < 2 {
+		return ""
+	}
+	for i, word := range words {
+		runes := []rune(word)
+		for _, r := range runes {
+			if !unicode.IsLetter(r) {
+				return ""
+			}
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+func mostCommon(names map[string]int) string {
+	best, bestCount := "", 0
+	for name, count := range names {
+		if count > bestCount || (count == bestCount && name < best) {
+			best, bestCount = name, count
+		}
+	}
+	return best
+}
+
+// Write saves the people as JSON to path
+func (p *People) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(p); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
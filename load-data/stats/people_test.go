@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DanielOsorio01/enron-email-search/load-data/email"
+)
+
+func TestBuildPeopleNames(t *testing.T) {
+	tests := []struct {
+		name string
+		to   string
+		xTo  string
+		want map[string]string
+	}{
+		{
+			name: "plain names",
+			to:   "ken.lay@enron.com, jeff.skilling@enron.com",
+			xTo:  "Ken Lay, Jeff Skilling",
+			want: map[string]string{"ken.lay@enron.com": "Ken Lay", "jeff.skilling@enron.com": "Jeff Skilling"},
+		},
+		{
+			name: "exchange paths",
+			to:   "kenneth.lay@enron.com, jeff.skilling@enron.com",
+			xTo:  "Lay, Kenneth </O=ENRON/OU=NA/CN=RECIPIENTS/CN=KLAY>, Skilling, Jeff </O=ENRON/OU=NA/CN=RECIPIENTS/CN=JSKILLIN>",
+			want: map[string]string{"kenneth.lay@enron.com": "Lay, Kenneth", "jeff.skilling@enron.com": "Skilling, Jeff"},
+		},
+		{
+			name: "quoted names",
+			to:   "kenneth.lay@enron.com, sherri.sera@enron.com",
+			xTo:  `"Lay, Kenneth" <kenneth.lay@enron.com>, "Sera, Sherri" <sherri.sera@enron.com>`,
+			want: map[string]string{"kenneth.lay@enron.com": "Lay, Kenneth", "sherri.sera@enron.com": "Sera, Sherri"},
+		},
+		{
+			name: "names not lining up",
+			to:   "kenneth.lay@enron.com, jeff.skilling@enron.com",
+			xTo:  "Lay, Kenneth, Skilling, Jeff",
+			want: map[string]string{"kenneth.lay@enron.com": "", "jeff.skilling@enron.com": ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Headers are split on commas as the email parser does
+			e := email.Email{From: "jeff.dasovich@enron.com", XTo: strings.Split(test.xTo, ",")}
+			for _, address := range strings.Split(test.to, ",") {
+				e.To = append(e.To, strings.TrimSpace(address))
+			}
+
+			names := map[string]string{}
+			for _, person := range BuildPeople([]email.Email{e}).People {
+				names[person.Address] = person.Name
+			}
+			for address, want := range test.want {
+				if names[address] != want {
+					t.Errorf("expected %s to be named %q, got %q", address, want, names[address])
+				}
+			}
+		})
+	}
+}
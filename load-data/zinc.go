@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+
+	"github.com/DanielOsorio01/enron-email-search/back/alias"
+	"github.com/DanielOsorio01/enron-email-search/back/dataset"
+	"github.com/DanielOsorio01/enron-email-search/back/db"
+	"github.com/DanielOsorio01/enron-email-search/load-data/metrics"
+)
+
+// newZincClient connects to ZincSearch with the ZINCSEARCH_URL, DB_USER and
+// DB_PASSWORD environment variables, read once for the whole run. Its bulk
+// requests are counted in the loader metrics.
+func newZincClient() *db.ZincClient {
+	options := db.DefaultOptions()
+	options.Observe = metrics.ObserveZinc
+	return db.NewZincClientWithOptions(
+		getEnv("ZINCSEARCH_URL", "http://localhost:4080"),
+		getEnv("DB_USER", "admin"),
+		getEnv("DB_PASSWORD", "Complexpass#123"),
+		options)
+}
+
+// datasetsIndex returns the dataset registry index named by the
+// DATASETS_INDEX environment variable, which the backend reads as well
+func datasetsIndex() string {
+	return getEnv("DATASETS_INDEX", dataset.Index)
+}
+
+// aliasesIndex returns the index of the alias pointers named by the
+// ALIASES_INDEX environment variable, which the backend reads as well
+func aliasesIndex() string {
+	return getEnv("ALIASES_INDEX", alias.Index)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
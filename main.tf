@@ -380,7 +380,7 @@ resource "aws_instance" "backend" {
                           sudo chmod 666 /var/run/docker.sock
                           aws ecr get-login-password --region us-east-1 | docker login --username AWS --password-stdin 418272755608.dkr.ecr.us-east-1.amazonaws.com
                           docker pull 418272755608.dkr.ecr.us-east-1.amazonaws.com/frontend
-                          docker run -d -p 3000:3000 --name backend -e DB_HOST=http://${aws_instance.database.private_ip}:4080 -e DB_USER=admin -e DB_PASSWORD=Complexpass#123 -e SERVER_PORT=3000 418272755608.dkr.ecr.us-east-1.amazonaws.com/backend:latest
+                          docker run -d -p 3000:3000 --name backend --health-cmd "curl -fs http://localhost:3000/healthz || exit 1" --health-interval 30s --health-timeout 10s --health-retries 3 -e DB_HOST=http://${aws_instance.database.private_ip}:4080 -e DB_USER=admin -e DB_PASSWORD=Complexpass#123 -e SERVER_PORT=3000 418272755608.dkr.ecr.us-east-1.amazonaws.com/backend:latest
                           EOF
 
   